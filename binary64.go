@@ -0,0 +1,237 @@
+package pagerank
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+// ErrBinaryFormat is returned by OpenBinary for files that are not in the
+// binary format written by WriteBinary
+var ErrBinaryFormat = errors.New("pagerank: invalid binary graph")
+
+// binaryMagic starts the files written by WriteBinary.
+const binaryMagic = "PRG1"
+
+// binaryHeader is the size of the header of the binary format, which keeps the
+// arrays after it aligned on 8 bytes.
+const binaryHeader = 24
+
+// WriteBinary writes the graph in a fixed layout binary format that OpenBinary
+// maps into memory without parsing. All the numbers are little endian:
+//
+//	magic   "PRG1"
+//	unused  uint32
+//	nodes   uint64, n
+//	edges   uint64, m
+//	ids     [n]uint64, the id of every node
+//	offsets [n+1]uint64, the edges of node i are at offsets[i]:offsets[i+1]
+//	targets [m]uint64, the node index of the target of every edge
+//	weights [m]float64, the normalized weight of every edge
+//
+// The edges of every node are sorted by target, and dangling nodes have none.
+func (g *Graph64) WriteBinary(w io.Writer) error {
+	g.normalize()
+	nodes := g.nodes
+
+	offsets := make([]uint64, len(nodes)+1)
+	targets := make([][]uint, len(nodes))
+	for source := range nodes {
+		node := &nodes[source]
+		if !node.dangling() {
+			row := make([]uint, 0, len(node.edges))
+			for target := range node.edges {
+				row = append(row, target)
+			}
+			sort.Slice(row, func(i, j int) bool { return row[i] < row[j] })
+			targets[source] = row
+		}
+		offsets[source+1] = offsets[source] + uint64(len(targets[source]))
+	}
+
+	out := bufio.NewWriter(w)
+	header := make([]byte, binaryHeader)
+	copy(header, binaryMagic)
+	binary.LittleEndian.PutUint64(header[8:], uint64(len(nodes)))
+	binary.LittleEndian.PutUint64(header[16:], offsets[len(nodes)])
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+	buffer := make([]byte, 8)
+	put := func(x uint64) error {
+		binary.LittleEndian.PutUint64(buffer, x)
+		_, err := out.Write(buffer)
+		return err
+	}
+	for _, id := range g.ids() {
+		if err := put(id); err != nil {
+			return err
+		}
+	}
+	for _, offset := range offsets {
+		if err := put(offset); err != nil {
+			return err
+		}
+	}
+	for _, row := range targets {
+		for _, target := range row {
+			if err := put(uint64(target)); err != nil {
+				return err
+			}
+		}
+	}
+	for source, row := range targets {
+		for _, target := range row {
+			if err := put(math.Float64bits(nodes[source].edges[target])); err != nil {
+				return err
+			}
+		}
+	}
+	return out.Flush()
+}
+
+// FrozenGraph64 is a read only graph opened by OpenBinary. Its arrays live in the
+// memory mapped file when possible, so opening a large graph is near instant and
+// its pages are shared between the processes that open it.
+type FrozenGraph64 struct {
+	Verbose bool
+	LastRun Run64
+	// MaxIterations, when positive, caps the number of iterations of a ranking,
+	// which is otherwise capped at powerIterations.
+	MaxIterations int
+	// Progress, when set, is called at the end of every iteration of a ranking
+	// with the iteration count and Δ.
+	Progress func(iteration int, Δ float64)
+	ids      []uint64
+	offsets  []uint64
+	targets  []uint64
+	weights  []float64
+	data     []byte
+	unmap    func([]byte) error
+}
+
+// OpenBinary opens a graph written by WriteBinary. The file is memory mapped on
+// the systems that support it, and read into memory on the others, or when the
+// host is not little endian. The graph must be closed with Close.
+func OpenBinary(path string) (*FrozenGraph64, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	g, err := frozen(data)
+	if err != nil {
+		unmap(data)
+		return nil, err
+	}
+	g.data, g.unmap = data, unmap
+	return g, nil
+}
+
+// frozen returns the graph held by data in the binary format.
+func frozen(data []byte) (*FrozenGraph64, error) {
+	if len(data) < binaryHeader || string(data[:4]) != binaryMagic {
+		return nil, ErrBinaryFormat
+	}
+	n := binary.LittleEndian.Uint64(data[8:])
+	m := binary.LittleEndian.Uint64(data[16:])
+	size := uint64(len(data)-binaryHeader) / 8
+	if n >= size || m > size || 2*n+1+2*m != size {
+		return nil, ErrBinaryFormat
+	}
+
+	words := words64(data[binaryHeader:])
+	g := &FrozenGraph64{
+		ids:     words[:n],
+		offsets: words[n : 2*n+1],
+		targets: words[2*n+1 : 2*n+1+m],
+	}
+	weights := words[2*n+1+m:]
+	g.weights = *(*[]float64)(unsafe.Pointer(&weights))
+	for i := uint64(0); i < n; i++ {
+		if g.offsets[i] > g.offsets[i+1] {
+			return nil, ErrBinaryFormat
+		}
+	}
+	if g.offsets[0] != 0 || g.offsets[n] != m {
+		return nil, ErrBinaryFormat
+	}
+	for _, target := range g.targets {
+		if target >= n {
+			return nil, ErrBinaryFormat
+		}
+	}
+	return g, nil
+}
+
+// littleEndian is set when the host stores numbers in little endian order, like
+// the binary format.
+var littleEndian = func() bool {
+	one := uint16(1)
+	return *(*byte)(unsafe.Pointer(&one)) == 1
+}()
+
+// words64 returns data, of a length that is a multiple of 8, as little endian
+// words. It aliases data when the host is little endian and data is aligned, and
+// decodes a copy otherwise.
+func words64(data []byte) []uint64 {
+	if len(data) == 0 {
+		return nil
+	}
+	if littleEndian && uintptr(unsafe.Pointer(&data[0]))%8 == 0 {
+		var words []uint64
+		header := (*reflect.SliceHeader)(unsafe.Pointer(&words))
+		header.Data = uintptr(unsafe.Pointer(&data[0]))
+		header.Len, header.Cap = len(data)/8, len(data)/8
+		return words
+	}
+	words := make([]uint64, len(data)/8)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	return words
+}
+
+// Close releases the memory mapped file of the graph, which must not be used
+// afterwards.
+func (g *FrozenGraph64) Close() error {
+	if g.unmap == nil {
+		return nil
+	}
+	data, unmap := g.data, g.unmap
+	g.ids, g.offsets, g.targets, g.weights, g.data, g.unmap = nil, nil, nil, nil, nil, nil
+	return unmap(data)
+}
+
+// Len returns the number of nodes of the graph.
+func (g *FrozenGraph64) Len() int {
+	return len(g.ids)
+}
+
+// Rank computes the PageRank of every node like Graph64.Rank, reading the edges
+// straight from the arrays of the file.
+func (g *FrozenGraph64) Rank(α, ε float64, callback func(id uint64, rank float64)) {
+	power := powerIteration{
+		verbose:       g.Verbose,
+		maxIterations: g.MaxIterations,
+		progress:      g.Progress,
+		dangling: func(source int) bool {
+			return g.offsets[source] == g.offsets[source+1]
+		},
+		push: func(source int, rank float64, next []float64) {
+			for e := g.offsets[source]; e < g.offsets[source+1]; e++ {
+				next[g.targets[e]] += rank * g.weights[e]
+			}
+		},
+	}
+	ranks := power.rank(len(g.ids), α, ε)
+	g.LastRun = power.run
+
+	for i, id := range g.ids {
+		callback(id, ranks[i])
+	}
+}
@@ -0,0 +1,66 @@
+package pagerank
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpenBinary64(t *testing.T) {
+	graph := scaleFree64(500, 3)
+	graph.AddNode(1 << 40)
+	graph.Link(1<<40+1, 1, 5e-324)
+
+	expected := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	dir, err := ioutil.TempDir("", "pagerank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "graph.bin")
+	buffer := bytes.Buffer{}
+	if err := graph.WriteBinary(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, buffer.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	frozen, err := OpenBinary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frozen.Len() != len(expected) {
+		t.Error("Expected", len(expected), "nodes but got", frozen.Len())
+	}
+	actual := map[uint64]float64{}
+	frozen.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected the ranks of the graph")
+	}
+	frozen.MaxIterations = 4
+	frozen.Rank(0.85, 0, func(node uint64, rank float64) {})
+	if run := frozen.LastRun; run.Iterations != 4 || run.Converged || run.Reason != ReasonMaxIterations {
+		t.Error("Expected the ranking to stop at 4 iterations but got", run)
+	}
+	if err := frozen.Close(); err != nil {
+		t.Error(err)
+	}
+
+	truncated := filepath.Join(dir, "truncated.bin")
+	if err := ioutil.WriteFile(truncated, buffer.Bytes()[:buffer.Len()-8], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenBinary(truncated); err != ErrBinaryFormat {
+		t.Error("Expected", ErrBinaryFormat, "but got", err)
+	}
+}
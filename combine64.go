@@ -0,0 +1,65 @@
+package pagerank
+
+// CombineRanks combines the ranks of separately ranked shards of a graph into an
+// approximation of the ranks of the whole graph, given the edges between shards.
+//
+// It follows BlockRank: the ranks of every shard are normalized to sum to 1,
+// then a graph of the shards is ranked, where every shard links to itself with
+// weight 1, standing for its internal edges, and to every other shard with the sum
+// of the cross edges between them weighted by the normalized rank of their source.
+// The rank of a node is its normalized rank within its shard times the rank of its
+// shard. The approximation assumes that the weights of the cross edges are on the
+// scale of the probability of following them, for example normalized by the
+// outbound weight of their source in the whole graph, and that shards are
+// disjoint: a node is attributed to the first shard holding it. Cross edges
+// between nodes that aren't in any shard are ignored.
+func CombineRanks(partials []map[uint64]float64, crossEdges *Graph64, α float64) map[uint64]float64 {
+	shards := make(map[uint64]int)
+	locals := make(map[uint64]float64)
+	for shard, partial := range partials {
+		sum := float64(0)
+		for _, rank := range partial {
+			sum += rank
+		}
+		for id, rank := range partial {
+			if _, ok := shards[id]; ok || sum == 0 {
+				continue
+			}
+			shards[id], locals[id] = shard, rank/sum
+		}
+	}
+
+	blocks := NewGraph64(len(partials))
+	for shard := range partials {
+		blocks.Link(uint64(shard), uint64(shard), 1)
+	}
+	if crossEdges != nil {
+		crossEdges.dequantize()
+		ids := crossEdges.ids()
+		for source := range crossEdges.nodes {
+			s, ok := shards[ids[source]]
+			if !ok {
+				continue
+			}
+			node := &crossEdges.nodes[source]
+			for target, weight := range node.edges {
+				t, ok := shards[ids[target]]
+				if !ok || s == t {
+					continue
+				}
+				blocks.Link(uint64(s), uint64(t), locals[ids[source]]*crossEdges.raw(node, weight))
+			}
+		}
+	}
+
+	ranks := make(map[uint64]float64, len(partials))
+	blocks.Rank(α, 1e-9, func(id uint64, rank float64) {
+		ranks[id] = rank
+	})
+
+	combined := make(map[uint64]float64, len(locals))
+	for id, local := range locals {
+		combined[id] = local * ranks[uint64(shards[id])]
+	}
+	return combined
+}
@@ -0,0 +1,52 @@
+package pagerank
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCombineRanks64(t *testing.T) {
+	first, second := NewGraph64(), NewGraph64()
+
+	first.Link(1, 2, 1.0)
+	first.Link(2, 1, 1.0)
+	first.Link(2, 3, 1.0)
+	first.Link(3, 1, 1.0)
+
+	second.Link(4, 5, 1.0)
+	second.Link(5, 4, 1.0)
+
+	partials := make([]map[uint64]float64, 2)
+	for i, shard := range []*Graph64{first, second} {
+		partials[i] = map[uint64]float64{}
+		shard.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+			partials[i][node] = rank
+		})
+	}
+
+	cross := NewGraph64()
+	cross.Link(3, 4, 0.5)
+	cross.Link(6, 1, 1.0)
+
+	combined := CombineRanks(partials, cross, 0.85)
+
+	if len(combined) != 5 {
+		t.Fatal("Expected 5 nodes but got", combined)
+	}
+	sum := float64(0)
+	for _, rank := range combined {
+		sum += rank
+	}
+	if math.Abs(sum-1) > 0.000001 {
+		t.Error("Expected combined ranks to sum to 1 but got", sum)
+	}
+	if math.Abs(combined[4]-combined[5]) > 0.000001 {
+		t.Error("Expected nodes 4 and 5 to have the same rank but got", combined)
+	}
+	if combined[4] <= 0.2 {
+		t.Error("Expected the second shard to gain rank from the cross edge but got", combined)
+	}
+	if ratio := combined[1] / combined[2]; math.Abs(ratio-partials[0][1]/partials[0][2]) > 0.000001 {
+		t.Error("Expected the ranks within a shard to keep their ratio but got", ratio)
+	}
+}
@@ -0,0 +1,265 @@
+package pagerank
+
+import "sort"
+
+// components labels every node with the root of its weakly connected component,
+// using union-find over the edges in either direction.
+func (g *Graph64) components() []uint {
+	g.dequantize()
+	parent := make([]uint, len(g.nodes))
+	for i := range parent {
+		parent[i] = uint(i)
+	}
+	find := func(i uint) uint {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	for source := range g.nodes {
+		for target := range g.nodes[source].edges {
+			s, t := find(uint(source)), find(target)
+			if s != t {
+				parent[s] = t
+			}
+		}
+	}
+	for i := range parent {
+		parent[i] = find(uint(i))
+	}
+	return parent
+}
+
+// IsWeaklyConnected reports whether every node can be reached from every other
+// node when the direction of the edges is ignored. Empty graphs are connected.
+func (g *Graph64) IsWeaklyConnected() bool {
+	components := g.components()
+	for _, component := range components {
+		if component != components[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsolatedNodes returns the ids of the nodes that have no edges to or from any
+// other node, sorted.
+func (g *Graph64) IsolatedNodes() []uint64 {
+	g.dequantize()
+	connected := make([]bool, len(g.nodes))
+	for source := range g.nodes {
+		for target := range g.nodes[source].edges {
+			if target != uint(source) {
+				connected[source], connected[target] = true, true
+			}
+		}
+	}
+	isolated := []uint64{}
+	for key, value := range g.index {
+		if !connected[value] {
+			isolated = append(isolated, key)
+		}
+	}
+	sort.Slice(isolated, func(i, j int) bool { return isolated[i] < isolated[j] })
+	return isolated
+}
+
+// hops returns the number of hops along outbound edges from the node s to every
+// node, up to maxHops, or -1 for the nodes that are farther or unreachable.
+// A negative maxHops doesn't limit the search.
+func (g *Graph64) hops(s uint, maxHops int) []int {
+	g.dequantize()
+
+	hops := make([]int, len(g.nodes))
+	for i := range hops {
+		hops[i] = -1
+	}
+	hops[s] = 0
+	queue := []uint{s}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		if maxHops >= 0 && hops[u] >= maxHops {
+			continue
+		}
+		for target := range g.nodes[u].edges {
+			if hops[target] < 0 {
+				hops[target] = hops[u] + 1
+				queue = append(queue, target)
+			}
+		}
+	}
+	return hops
+}
+
+// strong labels every node with a node of its strongly connected component,
+// using an iterative version of Tarjan's algorithm.
+func (g *Graph64) strong() []uint {
+	g.dequantize()
+	nodes := g.nodes
+	index, low := make([]int, len(nodes)), make([]int, len(nodes))
+	for i := range index {
+		index[i] = -1
+	}
+	onStack, labels := make([]bool, len(nodes)), make([]uint, len(nodes))
+	stack, counter := []uint{}, 0
+
+	// A frame is a node being visited, with the targets left to visit.
+	type frame struct {
+		node    uint
+		targets []uint
+	}
+	visit := func(v uint) frame {
+		index[v], low[v] = counter, counter
+		counter++
+		stack = append(stack, v)
+		onStack[v] = true
+		targets := make([]uint, 0, len(nodes[v].edges))
+		for target := range nodes[v].edges {
+			targets = append(targets, target)
+		}
+		return frame{node: v, targets: targets}
+	}
+	for root := range nodes {
+		if index[root] >= 0 {
+			continue
+		}
+		frames := []frame{visit(uint(root))}
+		for len(frames) > 0 {
+			top := len(frames) - 1
+			v := frames[top].node
+			if targets := frames[top].targets; len(targets) > 0 {
+				w := targets[0]
+				frames[top].targets = targets[1:]
+				if index[w] < 0 {
+					frames = append(frames, visit(w))
+				} else if onStack[w] && index[w] < low[v] {
+					low[v] = index[w]
+				}
+				continue
+			}
+
+			if low[v] == index[v] {
+				for {
+					w := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[w], labels[w] = false, v
+					if w == v {
+						break
+					}
+				}
+			}
+			frames = frames[:top]
+			if top > 0 {
+				if u := frames[top-1].node; low[v] < low[u] {
+					low[u] = low[v]
+				}
+			}
+		}
+	}
+	return labels
+}
+
+// DetectSinkClusters ranks the graph like Rank and returns the strongly connected
+// groups of at least two nodes that look like link farms: their internal link
+// density, the fraction of the possible edges between members that exist, and
+// their rank concentration, the fraction of the rank of the members that comes
+// from other members, are both at least threshold. The groups are sorted by
+// decreasing total rank, and the ids of every group in increasing order.
+func (g *Graph64) DetectSinkClusters(α, ε, threshold float64) [][]uint64 {
+	a := g.rank(α, ε)
+	nodes := g.nodes
+	labels := g.strong()
+
+	members := make(map[uint][]uint)
+	for i, label := range labels {
+		members[label] = append(members[label], uint(i))
+	}
+
+	ids := g.ids()
+	type cluster struct {
+		ids  []uint64
+		rank float64
+	}
+	clusters := []cluster{}
+	for label, group := range members {
+		k := len(group)
+		if k < 2 {
+			continue
+		}
+		edges, inflow, rank := 0, float64(0), float64(0)
+		for _, source := range group {
+			node := &nodes[source]
+			rank += node.weight[a]
+			for target, weight := range node.edges {
+				if labels[target] == label && target != source {
+					edges++
+					inflow += α * node.weight[a] * weight
+				}
+			}
+		}
+		density := float64(edges) / float64(k*(k-1))
+		if density < threshold || rank == 0 || inflow/rank < threshold {
+			continue
+		}
+		c := cluster{ids: make([]uint64, 0, k), rank: rank}
+		for _, i := range group {
+			c.ids = append(c.ids, ids[i])
+		}
+		sort.Slice(c.ids, func(i, j int) bool { return c.ids[i] < c.ids[j] })
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].rank != clusters[j].rank {
+			return clusters[i].rank > clusters[j].rank
+		}
+		return clusters[i].ids[0] < clusters[j].ids[0]
+	})
+
+	groups := make([][]uint64, 0, len(clusters))
+	for _, c := range clusters {
+		groups = append(groups, c.ids)
+	}
+	return groups
+}
+
+// HarmonicCentrality computes the harmonic centrality of every node, the sum of
+// the reciprocals of the number of hops along outbound edges from the node to
+// every other node it reaches. It runs a breadth first search from every node,
+// which takes O(V·(V+E)) time, spread over NumCPU goroutines.
+func (g *Graph64) HarmonicCentrality(callback func(id uint64, centrality float64)) {
+	g.dequantize()
+	centralities := make([]float64, len(g.nodes))
+	done := make(chan bool, 8)
+	harmonic := func(s uint) {
+		sum := float64(0)
+		for i, hops := range g.hops(s, -1) {
+			if hops > 0 && uint(i) != s {
+				sum += 1 / float64(hops)
+			}
+		}
+		centralities[s] = sum
+		done <- true
+	}
+	i, flight := 0, 0
+	for i < len(centralities) && flight < NumCPU {
+		go harmonic(uint(i))
+		flight++
+		i++
+	}
+	for i < len(centralities) {
+		<-done
+		flight--
+		go harmonic(uint(i))
+		flight++
+		i++
+	}
+	for j := 0; j < flight; j++ {
+		<-done
+	}
+
+	for key, value := range g.index {
+		callback(key, centralities[value])
+	}
+}
@@ -0,0 +1,108 @@
+package pagerank
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsWeaklyConnected64(t *testing.T) {
+	graph := NewGraph64()
+
+	if !graph.IsWeaklyConnected() {
+		t.Error("Expected an empty graph to be connected")
+	}
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(3, 2, 1.0)
+	graph.Link(4, 5, 1.0)
+
+	if graph.IsWeaklyConnected() {
+		t.Error("Expected the graph not to be connected")
+	}
+
+	graph.Link(5, 3, 1.0)
+
+	if !graph.IsWeaklyConnected() {
+		t.Error("Expected the graph to be connected")
+	}
+}
+
+func TestIsolatedNodes64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(3, 3, 1.0)
+	graph.Link(5, 2, 1.0)
+	graph.Pin(4, 0.1)
+
+	expected := []uint64{3, 4}
+	if actual := graph.IsolatedNodes(); !reflect.DeepEqual(actual, expected) {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestDetectSinkClusters64(t *testing.T) {
+	graph := NewGraph64()
+
+	// A chain feeding a farm of three nodes linking to each other only.
+	for i := uint64(1); i < 10; i++ {
+		graph.Link(i, i+1, 1.0)
+		graph.Link(i+1, i, 1.0)
+	}
+	graph.Link(5, 20, 1.0)
+	graph.Link(20, 21, 1.0)
+	graph.Link(20, 22, 1.0)
+	graph.Link(21, 20, 1.0)
+	graph.Link(21, 22, 1.0)
+	graph.Link(22, 20, 1.0)
+	graph.Link(22, 21, 1.0)
+
+	expected := [][]uint64{{20, 21, 22}}
+	if actual := graph.DetectSinkClusters(0.85, 0.000001, 0.7); !reflect.DeepEqual(actual, expected) {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	if actual := graph.DetectSinkClusters(0.85, 0.000001, 0.2); len(actual) != 2 {
+		t.Error("Expected the chain to be detected with a low threshold but got", actual)
+	}
+}
+
+func TestStrong64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 1, 1.0)
+	graph.Link(3, 4, 1.0)
+	graph.Link(4, 5, 1.0)
+	graph.Link(5, 4, 1.0)
+	graph.Link(6, 6, 1.0)
+
+	labels := graph.strong()
+	label := func(id uint64) uint {
+		return labels[graph.index[id]]
+	}
+	if label(1) != label(2) || label(1) != label(3) || label(4) != label(5) ||
+		label(1) == label(4) || label(6) == label(1) || label(6) == label(4) {
+		t.Error("Unexpected components", labels)
+	}
+}
+
+func TestHarmonicCentrality64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 4, 1.0)
+	graph.Link(1, 4, 1.0)
+	graph.Link(4, 4, 1.0)
+	graph.AddNode(5)
+
+	actual := map[uint64]float64{}
+	graph.HarmonicCentrality(func(node uint64, centrality float64) {
+		actual[node] = centrality
+	})
+	expected := map[uint64]float64{1: 1 + 0.5 + 1, 2: 1 + 0.5, 3: 1, 4: 0, 5: 0}
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
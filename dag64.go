@@ -0,0 +1,102 @@
+package pagerank
+
+import "errors"
+
+// ErrCyclic is returned when a ranking for acyclic graphs is given a graph
+// with a cycle
+var ErrCyclic = errors.New("pagerank: cyclic graph")
+
+// RankDAG computes the PageRank of every node of a directed acyclic graph in a
+// single pass over the nodes in topological order instead of iterating, which
+// gives the exact ranks much faster. Since the rank that teleports and leaks is
+// spread the same way, the ranks for a teleported mass of 1 are propagated along
+// the edges first, and then scaled to sum to 1. The teleport distribution and
+// the damping factors set on the graph are used. It returns ErrCyclic, without
+// ranking, if the graph has a cycle, including a self loop.
+func (g *Graph64) RankDAG(α float64, callback func(id uint64, rank float64)) error {
+	g.normalize()
+	nodes := g.nodes
+
+	// Kahn's algorithm orders the nodes, leaving out the nodes on cycles.
+	degrees := make([]int, len(nodes))
+	for source := range nodes {
+		if nodes[source].dangling() {
+			continue
+		}
+		for target := range nodes[source].edges {
+			degrees[target]++
+		}
+	}
+	order := make([]uint, 0, len(nodes))
+	for i, degree := range degrees {
+		if degree == 0 {
+			order = append(order, uint(i))
+		}
+	}
+	for i := 0; i < len(order); i++ {
+		node := &nodes[order[i]]
+		if node.dangling() {
+			continue
+		}
+		for target := range node.edges {
+			degrees[target]--
+			if degrees[target] == 0 {
+				order = append(order, target)
+			}
+		}
+	}
+	if len(order) < len(nodes) {
+		return ErrCyclic
+	}
+
+	teleport, alphas := g.teleport(nil), g.alphas(α)
+	inverse := 1 / float64(len(nodes))
+	a := 0
+	for i := range nodes {
+		nodes[i].weight[a] = inverse
+		if teleport != nil {
+			nodes[i].weight[a] = teleport[i]
+		}
+		nodes[i].weight[1-a] = 0
+	}
+	leak := float64(0)
+	for _, i := range order {
+		node := &nodes[i]
+		if node.dangling() {
+			leak += node.weight[a]
+			continue
+		}
+		aa := α * node.weight[a]
+		if alphas != nil {
+			aa = alphas[i] * node.weight[a]
+		}
+		for target, weight := range node.edges {
+			nodes[target].weight[a] += aa * weight
+		}
+	}
+
+	// The propagated ranks are the ranks for a teleported and leaked mass of 1,
+	// and scale to the ranks for the mass that makes them sum to 1.
+	total := float64(0)
+	for i := range nodes {
+		total += nodes[i].weight[a]
+	}
+	scale := 1 / total
+	for i := range nodes {
+		nodes[i].weight[a] *= scale
+	}
+
+	g.LastRun = Run64{
+		Iterations: 1,
+		Converged:  true,
+		Reason:     ReasonConverged,
+		Leaked:     leak * scale,
+	}
+	g.ranked, g.slot, g.rankedAlpha = true, a, α
+	g.online = nil
+
+	for key, value := range g.index {
+		callback(key, nodes[value].weight[a])
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package pagerank
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRankDAG64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(2, 4, 3.0)
+	graph.Link(3, 4, 1.0)
+	graph.Link(5, 4, 1.0)
+	graph.SetDamping(2, 0.5)
+
+	expected := map[uint64]float64{}
+	graph.Rank(0.85, 0.000000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	actual := map[uint64]float64{}
+	err := graph.RankDAG(0.85, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+	if run := graph.LastRun; run.Iterations != 1 || !run.Converged {
+		t.Error("Expected a single pass but got", run)
+	}
+
+	graph.Link(4, 4, 1.0)
+	if err := graph.RankDAG(0.85, func(node uint64, rank float64) {}); err != ErrCyclic {
+		t.Error("Expected", ErrCyclic, "but got", err)
+	}
+}
@@ -0,0 +1,54 @@
+package pagerank
+
+// ExplainPersonalized returns the approximate contribution of every seed to the
+// personalized PageRank of target, where the teleport distribution is given by
+// the weights of the seeds that are part of the graph. The contributions are
+// computed with reverse push from the target, along the inbound edges of the
+// nodes that are not dangling, until the residual of every node is below ε, and
+// sum to the personalized rank of the target. Leaks through dangling nodes are
+// ignored.
+func (g *Graph64) ExplainPersonalized(target uint64, seeds map[uint64]float64, α, ε float64) map[uint64]float64 {
+	contributions := make(map[uint64]float64, len(seeds))
+	t, ok := g.index[target]
+	if !ok {
+		return contributions
+	}
+
+	inbound := g.inbound()
+	estimate, residual := make([]float64, len(g.nodes)), make([]float64, len(g.nodes))
+	queued := make([]bool, len(g.nodes))
+	residual[t], queued[t] = 1, true
+	queue := []uint{t}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		queued[u] = false
+
+		r := residual[u]
+		estimate[u] += (1 - α) * r
+		residual[u] = 0
+		for _, link := range inbound[u] {
+			w := link.node
+			residual[w] += α * r * link.weight
+			if !queued[w] && residual[w] > ε {
+				queued[w] = true
+				queue = append(queue, w)
+			}
+		}
+	}
+
+	sum := float64(0)
+	for id, weight := range seeds {
+		if _, ok := g.index[id]; ok && weight > 0 {
+			sum += weight
+		}
+	}
+	for id, weight := range seeds {
+		s, ok := g.index[id]
+		if !ok || weight <= 0 {
+			continue
+		}
+		contributions[id] = weight / sum * estimate[s]
+	}
+	return contributions
+}
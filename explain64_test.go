@@ -0,0 +1,33 @@
+package pagerank
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestExplainPersonalized64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 1, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	actual := graph.ExplainPersonalized(3, map[uint64]float64{1: 1, 2: 1, 5: 1}, 0.85, 1e-9)
+	expected := map[uint64]float64{
+		1: 0.17148168439175737,
+		2: 0.1637106110935715,
+	}
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	teleport := make([]float64, 3)
+	teleport[graph.index[1]], teleport[graph.index[2]] = 0.5, 0.5
+	a := graph.iterate(&settings64{α: 0.85, ε: 1e-9, teleport: teleport})
+	if rank := graph.nodes[graph.index[3]].weight[a]; math.Abs(rank-actual[1]-actual[2]) > 1e-6 {
+		t.Error("Expected contributions to sum to", rank)
+	}
+}
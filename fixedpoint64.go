@@ -0,0 +1,127 @@
+package pagerank
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// RankFixedPoint computes the PageRank of every node with exactly the given number
+// of iterations, like RankFixed, but in fixed point integer arithmetic, so that
+// every machine computes the same ranks bit for bit, as consensus systems need.
+// Ranks and transition probabilities are integers in units of 1/scale, and rank
+// is passed to callback in these units; the ranks always sum to scale exactly.
+// α and the transition probabilities are rounded down to units once, and every
+// contribution along an edge is rounded down; the units lost to rounding are
+// teleported with the rest. The precision is therefore about the number of edges
+// over scale, which suggests a scale such as 1<<40 for large graphs. The edge
+// weights are converted from the floating point weights of the graph, so the
+// graph must be linked with the same edges in the same order on every machine.
+// The teleport distribution is uniform and the damping factors set on the graph
+// are not used. Nodes are processed in increasing order of their ids, and scale
+// must be positive.
+func (g *Graph64) RankFixedPoint(α float64, scale uint64, iterations int, callback func(id uint64, rank uint64)) {
+	g.denormalize()
+	nodes := g.nodes
+	n := uint64(len(nodes))
+	if n == 0 || scale == 0 {
+		return
+	}
+	if α < 0 {
+		α = 0
+	} else if α > 1 {
+		α = 1
+	}
+	damping := uint64(float64(α) * float64(scale))
+	if damping > scale {
+		damping = scale
+	}
+
+	// order holds the indices of the nodes sorted by id.
+	ids := g.ids()
+	order := make([]uint, len(nodes))
+	for i := range order {
+		order[i] = uint(i)
+	}
+	sort.Slice(order, func(i, j int) bool { return ids[order[i]] < ids[order[j]] })
+
+	// The transition probabilities, sorted by target.
+	type edge struct {
+		target      uint
+		probability uint64
+	}
+	edges := make([][]edge, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		targets := make([]uint, 0, len(node.edges))
+		for target := range node.edges {
+			targets = append(targets, target)
+		}
+		sort.Slice(targets, func(a, b int) bool { return ids[targets[a]] < ids[targets[b]] })
+		outbound := float64(0)
+		for _, target := range targets {
+			outbound += node.edges[target]
+		}
+		if !(outbound >= minOutbound64) {
+			continue
+		}
+		for _, target := range targets {
+			p := node.edges[target] / outbound * float64(scale)
+			if p <= 0 {
+				continue
+			}
+			probability := uint64(p)
+			if probability > scale {
+				probability = scale
+			}
+			edges[i] = append(edges[i], edge{target: target, probability: probability})
+		}
+	}
+
+	// times returns x*y/scale rounded down, for x and y at most scale.
+	times := func(x, y uint64) uint64 {
+		hi, lo := bits.Mul64(x, y)
+		quotient, _ := bits.Div64(hi, lo, scale)
+		return quotient
+	}
+	ranks, next := make([]uint64, len(nodes)), make([]uint64, len(nodes))
+	share, rest := scale/n, scale%n
+	for k, i := range order {
+		ranks[i] = share
+		if uint64(k) < rest {
+			ranks[i]++
+		}
+	}
+	for iteration := 0; iteration < iterations; iteration++ {
+		for i := range next {
+			next[i] = 0
+		}
+		propagated := uint64(0)
+		for _, i := range order {
+			flow := times(damping, ranks[i])
+			for _, e := range edges[i] {
+				contribution := times(flow, e.probability)
+				next[e.target] += contribution
+				propagated += contribution
+			}
+		}
+		// Everything that isn't propagated along edges teleports uniformly.
+		mass := scale - propagated
+		share, rest := mass/n, mass%n
+		for k, i := range order {
+			next[i] += share
+			if uint64(k) < rest {
+				next[i]++
+			}
+		}
+		ranks, next = next, ranks
+	}
+
+	g.LastRun = Run64{
+		Iterations: iterations,
+		Converged:  false,
+		Reason:     ReasonIterations,
+	}
+	for _, i := range order {
+		callback(ids[i], ranks[i])
+	}
+}
@@ -0,0 +1,55 @@
+package pagerank
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRankFixedPoint64(t *testing.T) {
+	graph := scaleFree64(500, 3)
+	graph.AddNode(1000)
+
+	expected := map[uint64]float64{}
+	graph.RankFixed(0.85, 30, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	const scale = 1 << 40
+	var first map[uint64]uint64
+	for i := 0; i < 2; i++ {
+		actual, sum := map[uint64]uint64{}, uint64(0)
+		graph.RankFixedPoint(0.85, scale, 30, func(node uint64, rank uint64) {
+			actual[node] = rank
+			sum += rank
+		})
+		if sum != scale {
+			t.Error("Expected the ranks to sum to", uint64(scale), "but got", sum)
+		}
+		if run := graph.LastRun; run.Converged || run.Reason != ReasonIterations || run.Iterations != 30 {
+			t.Error("Expected a run of 30 iterations like RankFixed but got", run)
+		}
+		if first == nil {
+			first = actual
+		} else if !reflect.DeepEqual(first, actual) {
+			t.Error("Expected identical ranks from every run")
+		}
+	}
+
+	for id, rank := range expected {
+		if math.Abs(float64(first[id])/scale-rank) > 0.000001 {
+			t.Error("Expected", rank, "for", id, "but got", float64(first[id])/scale)
+			break
+		}
+	}
+
+	var ids []uint64
+	graph.RankFixedPoint(0.85, scale, 1, func(node uint64, rank uint64) {
+		ids = append(ids, node)
+	})
+	for i := 1; i < len(ids); i++ {
+		if ids[i-1] >= ids[i] {
+			t.Fatal("Expected the nodes in increasing order of ids")
+		}
+	}
+}
@@ -0,0 +1,93 @@
+//go:build go1.18
+// +build go1.18
+
+package pagerank
+
+import (
+	"math"
+	"testing"
+)
+
+func FuzzLink64(f *testing.F) {
+	f.Add(uint64(1), uint64(2), math.Float64bits(1.0), math.Float64bits(math.NaN()))
+	f.Add(uint64(1), uint64(1), math.Float64bits(math.MaxFloat64), math.Float64bits(math.MaxFloat64))
+	f.Add(uint64(3), uint64(4), math.Float64bits(math.Inf(-1)), math.Float64bits(-1.0))
+	f.Fuzz(func(t *testing.T, source, target, a, b uint64) {
+		graph := NewGraph64()
+		graph.Link(source, target, math.Float64frombits(a))
+		graph.Link(source, target, math.Float64frombits(b))
+		graph.Link(target, source, math.Float64frombits(b))
+		for i := range graph.nodes {
+			node := &graph.nodes[i]
+			if !finite(node.outbound) {
+				t.Fatal("Non-finite outbound", node.outbound)
+			}
+			for _, weight := range node.edges {
+				if !finite(weight) {
+					t.Fatal("Non-finite weight", weight)
+				}
+			}
+		}
+	})
+}
+
+func FuzzLink32(f *testing.F) {
+	f.Add(uint64(1), uint64(2), math.Float32bits(1.0), math.Float32bits(float32(math.NaN())))
+	f.Add(uint64(1), uint64(1), math.Float32bits(math.MaxFloat32), math.Float32bits(math.MaxFloat32))
+	f.Add(uint64(3), uint64(4), math.Float32bits(float32(math.Inf(-1))), math.Float32bits(-1.0))
+	f.Fuzz(func(t *testing.T, source, target uint64, a, b uint32) {
+		graph := NewGraph32()
+		graph.Link(source, target, math.Float32frombits(a))
+		graph.Link(source, target, math.Float32frombits(b))
+		graph.Link(target, source, math.Float32frombits(b))
+		for i := range graph.nodes {
+			node := &graph.nodes[i]
+			if !finite(float64(node.outbound)) {
+				t.Fatal("Non-finite outbound", node.outbound)
+			}
+			for _, weight := range node.edges {
+				if !finite(float64(weight)) {
+					t.Fatal("Non-finite weight", weight)
+				}
+			}
+		}
+	})
+}
+
+func FuzzRank(f *testing.F) {
+	f.Add([]byte{1, 2, 16, 2, 3, 16, 3, 1, 16}, 0.85, false)
+	f.Add([]byte{1, 1, 255, 1, 2, 254, 2, 2, 253, 2, 1, 1}, 1.0, true)
+	f.Add([]byte{0, 255, 0, 255, 0, 16, 0, 255, 16, 7}, 0.0, false)
+	f.Fuzz(func(t *testing.T, links []byte, α float64, deterministic bool) {
+		if !(α >= 0 && α <= 1) {
+			t.Skip()
+		}
+		// Every link is three bytes: the source, the target, and the weight, with
+		// the highest weights standing for special values. Ids repeat their byte
+		// at both ends to be huge.
+		weights := map[byte]float64{255: math.NaN(), 254: math.Inf(1), 253: math.MaxFloat64, 252: 5e-324}
+		graph := NewGraph64()
+		graph.Deterministic = deterministic
+		graph.MaxIterations = 1000
+		for i := 0; i+2 < len(links); i += 3 {
+			source := uint64(links[i])<<56 | uint64(links[i])
+			target := uint64(links[i+1])<<56 | uint64(links[i+1])
+			weight, ok := weights[links[i+2]]
+			if !ok {
+				weight = float64(links[i+2]) / 16
+			}
+			graph.Link(source, target, weight)
+		}
+
+		sum := float64(0)
+		graph.Rank(α, 0.000001, func(node uint64, rank float64) {
+			if !finite(rank) || rank < 0 {
+				t.Fatal("Invalid rank", rank, "for", node)
+			}
+			sum += rank
+		})
+		if len(graph.nodes) > 0 && math.Abs(sum-1) > 0.000001 {
+			t.Fatal("Expected the ranks to sum to 1 but got", sum)
+		}
+	})
+}
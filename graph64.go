@@ -0,0 +1,513 @@
+package pagerank
+
+import (
+	"math"
+	"sort"
+)
+
+// tolerance64 is the relative tolerance used when comparing weights.
+const tolerance64 = 1e-9
+
+// approximately reports whether two weights are equal within tolerance64.
+func approximately(a, b float64) bool {
+	scale := math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+	return math.Abs(a-b) <= tolerance64*scale
+}
+
+// raw returns the weight of an edge as it was linked, undoing normalization.
+func (g *Graph64) raw(node *Node64, weight float64) float64 {
+	if g.normalized {
+		return weight * node.outbound
+	}
+	return weight
+}
+
+// Equal reports whether two graphs have the same nodes and the same edges, with
+// weights equal within a small tolerance. Internal indices and normalization are
+// not taken into account.
+func (g *Graph64) Equal(other *Graph64) bool {
+	g.dequantize()
+	other.dequantize()
+	if len(g.index) != len(other.index) {
+		return false
+	}
+	ids := g.ids()
+	for id, s := range g.index {
+		o, ok := other.index[id]
+		if !ok {
+			return false
+		}
+		node, match := &g.nodes[s], &other.nodes[o]
+		if len(node.edges) != len(match.edges) {
+			return false
+		}
+		for t, weight := range node.edges {
+			u, ok := other.index[ids[t]]
+			if !ok {
+				return false
+			}
+			w, ok := match.edges[u]
+			if !ok || !approximately(g.raw(node, weight), other.raw(match, w)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ScaleOutbound multiplies the weight of every outbound edge of a node by factor.
+// Like Link, it has to be called before Rank to be reflected in the ranks. Since
+// edge weights are normalized per node, only edges linked afterwards, or a factor
+// of zero, change the transition probabilities of the node.
+func (g *Graph64) ScaleOutbound(id uint64, factor float64) {
+	s, ok := g.index[id]
+	if !ok {
+		return
+	}
+	g.denormalize()
+	node := &g.nodes[s]
+	for target := range node.edges {
+		node.edges[target] *= factor
+	}
+	node.outbound *= factor
+}
+
+// edges returns the number of edges in the graph.
+func (g *Graph64) edges() int {
+	g.dequantize()
+	edges := 0
+	for i := range g.nodes {
+		edges += len(g.nodes[i].edges)
+	}
+	return edges
+}
+
+// Density returns the fraction of all possible directed edges that exist.
+func (g *Graph64) Density() float64 {
+	n := float64(len(g.nodes))
+	if n < 2 {
+		return 0
+	}
+	return float64(g.edges()) / (n * (n - 1))
+}
+
+// Reciprocity returns the fraction of edges whose reverse edge also exists.
+// Self-loops are ignored.
+func (g *Graph64) Reciprocity() float64 {
+	g.dequantize()
+	edges, reciprocated := 0, 0
+	for source := range g.nodes {
+		for target := range g.nodes[source].edges {
+			if target == uint(source) {
+				continue
+			}
+			edges++
+			if _, ok := g.nodes[target].edges[uint(source)]; ok {
+				reciprocated++
+			}
+		}
+	}
+	if edges == 0 {
+		return 0
+	}
+	return float64(reciprocated) / float64(edges)
+}
+
+// AvgOutDegree returns the average number of outbound edges per node.
+func (g *Graph64) AvgOutDegree() float64 {
+	if len(g.nodes) == 0 {
+		return 0
+	}
+	return float64(g.edges()) / float64(len(g.nodes))
+}
+
+// MapWeights replaces the weight of every edge with fn(weight), for example
+// math.Log1p to dampen hubs, and recomputes the outbound weight of every node.
+// Like Link, it removes the edges whose new weight is NaN or infinite, or would
+// overflow the outbound weight of their node, and counts them in Rejected.
+func (g *Graph64) MapWeights(fn func(weight float64) float64) {
+	g.denormalize()
+	for i := range g.nodes {
+		node := &g.nodes[i]
+		node.outbound = 0
+		for target, weight := range node.edges {
+			weight = fn(weight)
+			if !finite(weight) || !finite(node.outbound+weight) {
+				delete(node.edges, target)
+				g.Rejected++
+				continue
+			}
+			node.edges[target] = weight
+			node.outbound += weight
+		}
+	}
+}
+
+// LineGraph returns the line graph of the graph, whose nodes are the edges of the
+// graph: edge u→v links to every edge v→w, weighted by the weight of v→w. Ranking
+// the line graph ranks the edges. The id of the node of an edge is its position in
+// the returned slice of endpoints, in which edges are sorted by source and target.
+// The line graph has one edge for every pair of inbound and outbound edges of
+// every node, which can be far more than the graph itself for hubs.
+func (g *Graph64) LineGraph() (*Graph64, [][2]uint64) {
+	g.dequantize()
+	ids := g.ids()
+	endpoints := make([][2]uint64, 0, g.edges())
+	for source := range g.nodes {
+		for target := range g.nodes[source].edges {
+			endpoints = append(endpoints, [2]uint64{ids[source], ids[target]})
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i][0] == endpoints[j][0] {
+			return endpoints[i][1] < endpoints[j][1]
+		}
+		return endpoints[i][0] < endpoints[j][0]
+	})
+
+	outbound := make(map[uint64][]uint64)
+	for id, edge := range endpoints {
+		outbound[edge[0]] = append(outbound[edge[0]], uint64(id))
+	}
+
+	line := NewGraph64(len(endpoints))
+	for id, edge := range endpoints {
+		line.add(uint64(id))
+		for _, next := range outbound[edge[1]] {
+			from := endpoints[next]
+			node := &g.nodes[g.index[from[0]]]
+			line.Link(uint64(id), next, g.raw(node, node.edges[g.index[from[1]]]))
+		}
+	}
+	return line, endpoints
+}
+
+// DanglingNodes returns the ids of the nodes without outbound weight, or with too
+// little to normalize by, sorted.
+// Their rank leaks to every node like teleportation.
+func (g *Graph64) DanglingNodes() []uint64 {
+	dangling := []uint64{}
+	for key, value := range g.index {
+		if g.nodes[value].dangling() {
+			dangling = append(dangling, key)
+		}
+	}
+	sort.Slice(dangling, func(i, j int) bool { return dangling[i] < dangling[j] })
+	return dangling
+}
+
+// Edge is a weighted edge between a source-target node pair.
+type Edge struct {
+	Source, Target uint64
+	Weight         float64
+}
+
+// TopInbound returns the n highest weighted edges pointing at a node, with their
+// weights as linked, sorted by decreasing weight and then by source. The inbound
+// edges are built on the first call and cached until the graph is modified.
+func (g *Graph64) TopInbound(id uint64, n int) []Edge {
+	t, ok := g.index[id]
+	if !ok || n <= 0 {
+		return nil
+	}
+	ids := g.ids()
+	inbound := g.inbound()[t]
+	edges := make([]Edge, 0, len(inbound))
+	for _, link := range inbound {
+		edges = append(edges, Edge{
+			Source: ids[link.node],
+			Target: id,
+			Weight: g.raw(&g.nodes[link.node], link.weight),
+		})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Weight != edges[j].Weight {
+			return edges[i].Weight > edges[j].Weight
+		}
+		return edges[i].Source < edges[j].Source
+	})
+	if len(edges) > n {
+		edges = edges[:n]
+	}
+	return edges
+}
+
+// BuildFromChannel links every edge received from ch until it is closed. The
+// edges are linked in batches, growing the node storage at most once per batch,
+// by doubling it, so that building the graph takes linear time. Edges rejected by
+// LinkChecked are skipped and counted in Rejected.
+func (g *Graph64) BuildFromChannel(ch <-chan Edge) {
+	const size = 1024
+	batch := make([]Edge, 0, size)
+	link := func() {
+		if grow := len(g.nodes) + 2*len(batch); grow > cap(g.nodes) {
+			if grow < 2*cap(g.nodes) {
+				grow = 2 * cap(g.nodes)
+			}
+			nodes := make([]Node64, len(g.nodes), grow)
+			copy(nodes, g.nodes)
+			g.nodes = nodes
+		}
+		for _, edge := range batch {
+			g.Link(edge.Source, edge.Target, edge.Weight)
+		}
+		batch = batch[:0]
+	}
+	for edge := range ch {
+		batch = append(batch, edge)
+		if len(batch) == size {
+			link()
+		}
+	}
+	link()
+}
+
+// sortedEdges returns every edge with its weight as linked, sorted by source and
+// target.
+func (g *Graph64) sortedEdges() []Edge {
+	g.dequantize()
+	ids := g.ids()
+	edges := make([]Edge, 0, g.edges())
+	for source := range g.nodes {
+		node := &g.nodes[source]
+		for target, weight := range node.edges {
+			edges = append(edges, Edge{
+				Source: ids[source],
+				Target: ids[target],
+				Weight: g.raw(node, weight),
+			})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source == edges[j].Source {
+			return edges[i].Target < edges[j].Target
+		}
+		return edges[i].Source < edges[j].Source
+	})
+	return edges
+}
+
+// Project returns the one mode projection of a bipartite graph onto the nodes
+// whose partition is side: two such nodes are linked in both directions, with a
+// weight equal to the number of neighbors they share on the other sides. Edges are
+// followed in either direction and their weights are ignored; nodes missing from
+// partition are dropped. Every node of side is in the projection, even without
+// shared neighbors.
+func (g *Graph64) Project(partition map[uint64]int, side int) *Graph64 {
+	g.dequantize()
+	ids := g.ids()
+	kept := func(i uint) bool {
+		p, ok := partition[ids[i]]
+		return ok && p == side
+	}
+
+	// neighbors holds, for every node of the other sides, its neighbors of side.
+	neighbors := make([]map[uint]bool, len(g.nodes))
+	connect := func(node, neighbor uint) {
+		if _, ok := partition[ids[node]]; !ok || kept(node) || !kept(neighbor) {
+			return
+		}
+		if neighbors[node] == nil {
+			neighbors[node] = make(map[uint]bool)
+		}
+		neighbors[node][neighbor] = true
+	}
+	for source := range g.nodes {
+		for target := range g.nodes[source].edges {
+			connect(uint(source), target)
+			connect(target, uint(source))
+		}
+	}
+
+	projection := NewGraph64()
+	for i := range g.nodes {
+		if kept(uint(i)) {
+			projection.add(ids[i])
+		}
+	}
+	for _, shared := range neighbors {
+		members := make([]uint, 0, len(shared))
+		for member := range shared {
+			members = append(members, member)
+		}
+		sort.Slice(members, func(i, j int) bool {
+			return members[i] < members[j]
+		})
+		for i, u := range members {
+			for _, v := range members[i+1:] {
+				projection.Link(ids[u], ids[v], 1)
+				projection.Link(ids[v], ids[u], 1)
+			}
+		}
+	}
+	return projection
+}
+
+// TrimDangling removes the dangling nodes from the graph, with their inbound
+// edges, over and over until no node is dangling, since removing the targets of
+// the edges of a node can leave it dangling, and returns the number of nodes
+// removed. The graph keeps the nodes that can reach a cycle, which changes the
+// ranks of the nodes that remain: they no longer lose rank to, or receive the
+// leaked rank of, the removed nodes. The settings of the removed nodes are
+// dropped.
+func (g *Graph64) TrimDangling() int {
+	removed := 0
+	for {
+		trimmed := g.trimDangling()
+		if trimmed == 0 {
+			return removed
+		}
+		removed += trimmed
+	}
+}
+
+// trimDangling removes the nodes that are dangling or whose edges all lead to
+// nodes that are removed, and returns how many it removed.
+func (g *Graph64) trimDangling() int {
+	g.denormalize()
+	nodes := g.nodes
+
+	sources := make([][]uint, len(nodes))
+	kept := make([]int, len(nodes))
+	removed := make([]bool, len(nodes))
+	queue := []uint{}
+	for source := range nodes {
+		node := &nodes[source]
+		kept[source] = len(node.edges)
+		for target := range node.edges {
+			sources[target] = append(sources[target], uint(source))
+		}
+		if node.dangling() {
+			removed[source] = true
+			queue = append(queue, uint(source))
+		}
+	}
+	for len(queue) > 0 {
+		target := queue[0]
+		queue = queue[1:]
+		for _, source := range sources[target] {
+			if removed[source] {
+				continue
+			}
+			kept[source]--
+			if kept[source] == 0 {
+				removed[source] = true
+				queue = append(queue, source)
+			}
+		}
+	}
+
+	// The nodes that remain keep their order and are renumbered.
+	numbers, count := make([]uint, len(nodes)), uint(0)
+	for i := range nodes {
+		if !removed[i] {
+			numbers[i] = count
+			count++
+		}
+	}
+	if int(count) == len(nodes) {
+		return 0
+	}
+	for id, i := range g.index {
+		if removed[i] {
+			delete(g.index, id)
+		} else {
+			g.index[id] = numbers[i]
+		}
+	}
+	for i := range nodes {
+		if removed[i] {
+			continue
+		}
+		node := &nodes[i]
+		edges := make(map[uint]float64, len(node.edges))
+		node.outbound = 0
+		for target, weight := range node.edges {
+			if !removed[target] {
+				edges[numbers[target]] = weight
+				node.outbound += weight
+			}
+		}
+		node.edges = edges
+		node.move(&nodes[numbers[i]])
+	}
+	var empty Node64
+	for i := count; i < uint(len(nodes)); i++ {
+		empty.move(&nodes[i])
+	}
+	g.nodes, g.count = nodes[:count], count
+
+	renumber := func(i uint) (uint, bool) {
+		return numbers[i], !removed[i]
+	}
+	g.pins = renumberFloats(g.pins, renumber)
+	g.damping = renumberFloats(g.damping, renumber)
+	g.restart = renumberFloats(g.restart, renumber)
+	if g.noTeleport != nil {
+		noTeleport := make(map[uint]bool, len(g.noTeleport))
+		for i, value := range g.noTeleport {
+			if j, ok := renumber(i); ok {
+				noTeleport[j] = value
+			}
+		}
+		g.noTeleport = noTeleport
+	}
+	if g.timestamps != nil {
+		timestamps := make(map[uint]int64, len(g.timestamps))
+		for i, value := range g.timestamps {
+			if j, ok := renumber(i); ok {
+				timestamps[j] = value
+			}
+		}
+		g.timestamps = timestamps
+	}
+	if g.constraints != nil {
+		constraints := make(map[uint]Constraint, len(g.constraints))
+		for i, value := range g.constraints {
+			if j, ok := renumber(i); ok {
+				constraints[j] = value
+			}
+		}
+		g.constraints = constraints
+	}
+	if g.typed != nil {
+		typed := make(map[typedEdge64]float64, len(g.typed))
+		for edge, weight := range g.typed {
+			source, ok := renumber(edge.source)
+			target, ok2 := renumber(edge.target)
+			if ok && ok2 {
+				typed[typedEdge64{source: source, target: target, kind: edge.kind}] = weight
+			}
+		}
+		g.typed = typed
+	}
+	if g.layers != nil {
+		layers := make(map[layeredEdge64]float64, len(g.layers))
+		for edge, weight := range g.layers {
+			source, ok := renumber(edge.source)
+			target, ok2 := renumber(edge.target)
+			if ok && ok2 {
+				layers[layeredEdge64{source: source, target: target, layer: edge.layer}] = weight
+			}
+		}
+		g.layers = layers
+	}
+	g.preferred = nil
+	g.ranked, g.online = false, nil
+	return len(nodes) - int(count)
+}
+
+// renumberFloats returns the values of a map keyed by internal index keyed by the
+// new indices given by renumber, without the removed nodes.
+func renumberFloats(values map[uint]float64, renumber func(i uint) (uint, bool)) map[uint]float64 {
+	if values == nil {
+		return nil
+	}
+	renumbered := make(map[uint]float64, len(values))
+	for i, value := range values {
+		if j, ok := renumber(i); ok {
+			renumbered[j] = value
+		}
+	}
+	return renumbered
+}
@@ -0,0 +1,350 @@
+package pagerank
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestEqual64(t *testing.T) {
+	a, b := NewGraph64(), NewGraph64()
+
+	a.Link(1, 2, 1.0)
+	a.Link(1, 3, 2.0)
+	a.Link(2, 3, 3.0)
+
+	b.Link(2, 3, 3.0)
+	b.Link(1, 3, 2.0)
+	b.Link(1, 2, 1.0)
+
+	if !a.Equal(b) || !b.Equal(a) {
+		t.Error("Expected graphs to be equal")
+	}
+
+	a.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+	if !a.Equal(b) {
+		t.Error("Expected graphs to be equal after normalization")
+	}
+
+	b.Link(1, 2, 0.5)
+	if a.Equal(b) {
+		t.Error("Expected graphs with different weights to differ")
+	}
+
+	a.Link(1, 2, 0.5)
+	a.Link(3, 1, 1.0)
+	if a.Equal(b) {
+		t.Error("Expected graphs with different edges to differ")
+	}
+}
+
+func TestScaleOutbound64(t *testing.T) {
+	a, b := NewGraph64(), NewGraph64()
+
+	a.Link(1, 2, 1.0)
+	a.Link(1, 3, 2.0)
+	a.Link(2, 3, 3.0)
+	a.ScaleOutbound(1, 3.0)
+
+	b.Link(1, 2, 3.0)
+	b.Link(1, 3, 6.0)
+	b.Link(2, 3, 3.0)
+
+	if !a.Equal(b) {
+		t.Error("Expected scaled graph to match")
+	}
+	if a.nodes[a.index[1]].outbound != 9.0 {
+		t.Error("Expected outbound of 9 but got", a.nodes[a.index[1]].outbound)
+	}
+}
+
+func TestLinkAsym64(t *testing.T) {
+	a, b := NewGraph64(), NewGraph64()
+
+	a.LinkAsym(1, 2, 1.0, 3.0)
+	a.LinkAsym(2, 3, 2.0, 0)
+
+	b.Link(1, 2, 1.0)
+	b.Link(2, 1, 3.0)
+	b.Link(2, 3, 2.0)
+
+	if !a.Equal(b) {
+		t.Error("Expected asymmetric links to match")
+	}
+}
+
+func TestStatistics64(t *testing.T) {
+	graph := NewGraph64()
+
+	if graph.Density() != 0 || graph.Reciprocity() != 0 || graph.AvgOutDegree() != 0 {
+		t.Error("Expected zero statistics for an empty graph")
+	}
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	if density := graph.Density(); density != 5.0/12.0 {
+		t.Error("Expected a density of", 5.0/12.0, "but got", density)
+	}
+	if reciprocity := graph.Reciprocity(); reciprocity != 2.0/5.0 {
+		t.Error("Expected a reciprocity of", 2.0/5.0, "but got", reciprocity)
+	}
+	if degree := graph.AvgOutDegree(); degree != 5.0/4.0 {
+		t.Error("Expected an average out degree of", 5.0/4.0, "but got", degree)
+	}
+}
+
+func TestMapWeights64(t *testing.T) {
+	a, b := NewGraph64(), NewGraph64()
+
+	a.Link(1, 2, 1.0)
+	a.Link(1, 3, 2.0)
+	a.Link(2, 3, 3.0)
+	a.MapWeights(func(weight float64) float64 {
+		return weight * weight
+	})
+
+	b.Link(1, 2, 1.0)
+	b.Link(1, 3, 4.0)
+	b.Link(2, 3, 9.0)
+
+	if !a.Equal(b) {
+		t.Error("Expected mapped graph to match")
+	}
+	if outbound := a.nodes[a.index[1]].outbound; outbound != 5.0 {
+		t.Error("Expected outbound of 5 but got", outbound)
+	}
+
+	a.MapWeights(func(weight float64) float64 {
+		if weight == 9.0 {
+			return math.NaN()
+		}
+		return weight
+	})
+	c := NewGraph64()
+	c.Link(1, 2, 1.0)
+	c.Link(1, 3, 4.0)
+	if !a.Equal(c) || a.Rejected != 1 {
+		t.Error("Expected the NaN weight to be rejected but got", a.Rejected, "rejected")
+	}
+}
+
+func TestMaxEdgesPerNode64(t *testing.T) {
+	a, b := NewGraph64(), NewGraph64()
+
+	a.MaxEdgesPerNode = 2
+	a.Link(1, 2, 3.0)
+	a.Link(1, 3, 1.0)
+	a.Link(1, 4, 2.0)
+	a.Link(1, 5, 0.5)
+	a.Link(2, 1, 1.0)
+
+	b.Link(1, 2, 3.0)
+	b.Link(1, 4, 2.0)
+	b.Link(2, 1, 1.0)
+	b.add(3)
+	b.add(5)
+
+	if !a.Equal(b) {
+		t.Error("Expected the lowest weighted edges to be evicted")
+	}
+	if outbound := a.nodes[a.index[1]].outbound; outbound != 5.0 {
+		t.Error("Expected outbound of 5 but got", outbound)
+	}
+}
+
+func TestLineGraph64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 2.0)
+	graph.Link(2, 1, 3.0)
+
+	line, endpoints := graph.LineGraph()
+
+	expected := [][2]uint64{{1, 2}, {2, 1}, {2, 3}}
+	if len(endpoints) != len(expected) {
+		t.Fatal("Expected", expected, "but got", endpoints)
+	}
+	for i := range expected {
+		if endpoints[i] != expected[i] {
+			t.Fatal("Expected", expected, "but got", endpoints)
+		}
+	}
+
+	other := NewGraph64()
+	other.Link(0, 1, 3.0)
+	other.Link(0, 2, 2.0)
+	other.Link(1, 0, 1.0)
+	other.add(2)
+
+	if !line.Equal(other) {
+		t.Error("Unexpected line graph")
+	}
+}
+
+func TestDanglingNodes64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(3, 5, 0.0)
+	graph.AddNode(4)
+
+	expected := []uint64{3, 4, 5}
+	if actual := graph.DanglingNodes(); !reflect.DeepEqual(actual, expected) {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestTopInbound64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(4, 3, 2.0)
+	graph.Link(5, 3, 1.0)
+	graph.Link(5, 1, 1.0)
+	graph.Link(3, 1, 5.0)
+
+	expected := []Edge{
+		{Source: 2, Target: 3, Weight: 3.0},
+		{Source: 1, Target: 3, Weight: 2.0},
+		{Source: 4, Target: 3, Weight: 2.0},
+	}
+	actual := graph.TopInbound(3, 3)
+	if len(actual) != len(expected) {
+		t.Fatal("Expected", expected, "but got", actual)
+	}
+	for i := range expected {
+		if actual[i].Source != expected[i].Source || actual[i].Target != expected[i].Target ||
+			!approximately(actual[i].Weight, expected[i].Weight) {
+			t.Error("Expected", expected, "but got", actual)
+			break
+		}
+	}
+
+	if edges := graph.TopInbound(2, 3); len(edges) != 0 {
+		t.Error("Expected no inbound edges but got", edges)
+	}
+	if edges := graph.TopInbound(6, 3); edges != nil {
+		t.Error("Expected nil for an unknown node but got", edges)
+	}
+}
+
+func TestBuildFromChannel64(t *testing.T) {
+	edges := make(chan Edge)
+	go func() {
+		for i := uint64(0); i < 3000; i++ {
+			edges <- Edge{Source: i, Target: (i + 1) % 3000, Weight: 1.0}
+		}
+		edges <- Edge{Source: 0, Target: 1, Weight: math.NaN()}
+		close(edges)
+	}()
+
+	graph := NewGraph64()
+	graph.BuildFromChannel(edges)
+
+	expected := NewGraph64()
+	for i := uint64(0); i < 3000; i++ {
+		expected.Link(i, (i+1)%3000, 1.0)
+	}
+	if !graph.Equal(expected) {
+		t.Error("Expected the streamed graph to equal the linked graph")
+	}
+	if graph.Rejected != 1 {
+		t.Error("Expected 1 rejected edge but got", graph.Rejected)
+	}
+}
+
+func TestProject64(t *testing.T) {
+	graph := NewGraph64()
+
+	// Users 1, 2 and 3 interact with items 10, 11 and 12.
+	graph.Link(1, 10, 1.0)
+	graph.Link(1, 11, 1.0)
+	graph.Link(2, 10, 2.0)
+	graph.Link(2, 11, 1.0)
+	graph.Link(12, 3, 1.0)
+	graph.Link(3, 10, 1.0)
+	graph.Link(4, 10, 1.0)
+
+	partition := map[uint64]int{1: 0, 2: 0, 3: 0, 10: 1, 11: 1, 12: 1}
+	actual := graph.Project(partition, 0)
+
+	expected := NewGraph64()
+	expected.Link(1, 2, 2.0)
+	expected.Link(2, 1, 2.0)
+	expected.Link(1, 3, 1.0)
+	expected.Link(3, 1, 1.0)
+	expected.Link(2, 3, 1.0)
+	expected.Link(3, 2, 1.0)
+	if !actual.Equal(expected) {
+		t.Error("Expected the user projection")
+	}
+
+	actual = graph.Project(partition, 1)
+	expected = NewGraph64()
+	expected.Link(10, 11, 2.0)
+	expected.Link(11, 10, 2.0)
+	expected.Link(10, 12, 1.0)
+	expected.Link(12, 10, 1.0)
+	if !actual.Equal(expected) {
+		t.Error("Expected the item projection")
+	}
+}
+
+func TestTrimDangling64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 1, 1.0)
+	graph.Link(3, 4, 1.0)
+	graph.Link(4, 5, 1.0)
+	graph.Link(5, 6, 1.0)
+	graph.Link(7, 7, 1.0)
+	graph.Link(1, 8, 1.0)
+	graph.Link(8, 9, 0.0)
+	graph.AddNode(10)
+	graph.SetDamping(7, 0.5)
+	graph.SetDamping(6, 0.5)
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+
+	if removed := graph.TrimDangling(); removed != 6 {
+		t.Error("Expected 6 nodes to be removed but got", removed)
+	}
+	if removed := graph.TrimDangling(); removed != 0 {
+		t.Error("Expected no node to be removed but got", removed)
+	}
+	if dangling := graph.DanglingNodes(); len(dangling) != 0 {
+		t.Error("Expected no dangling node but got", dangling)
+	}
+
+	expected := NewGraph64()
+	expected.Link(1, 2, 1.0)
+	expected.Link(2, 3, 1.0)
+	expected.Link(3, 1, 1.0)
+	expected.Link(7, 7, 1.0)
+	if !graph.Equal(expected) {
+		t.Error("Expected the graph to keep the cycles only")
+	}
+	if len(graph.damping) != 1 || graph.damping[graph.index[7]] != 0.5 {
+		t.Error("Expected the damping of node 7 to be kept but got", graph.damping)
+	}
+
+	actual, ranks := map[uint64]float64{}, map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	expected.SetDamping(7, 0.5)
+	expected.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		ranks[node] = rank
+	})
+	if reflect.DeepEqual(convert64(actual), convert64(ranks)) != true {
+		t.Error("Expected", ranks, "but got", actual)
+	}
+}
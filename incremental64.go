@@ -0,0 +1,205 @@
+package pagerank
+
+import "math"
+
+// incrementalPushes bounds the number of push steps of UpdateRankIncremental.
+const incrementalPushes = 4096
+
+// Ranks returns the ranks of the last ranking of the graph, as adjusted by
+// UpdateRankIncremental since, or nil if the graph has not been ranked.
+func (g *Graph64) Ranks() map[uint64]float64 {
+	if !g.ranked {
+		return nil
+	}
+	ranks := make(map[uint64]float64, len(g.index))
+	for key, value := range g.index {
+		ranks[key] = g.nodes[value].weight[g.slot]
+	}
+	return ranks
+}
+
+// UpdateRankIncremental links an edge like Link and adjusts the ranks of the last
+// ranking, returned by Ranks, for it instead of ranking the graph again. The
+// change of the rank flowing out of the source is pushed along the edges as a
+// residual, node by node, and the change of the rank leaked by dangling nodes is
+// pushed from every node, until the residuals are small compared to the average
+// rank or a bounded number of pushes is reached; the residual left is dropped.
+// The result is approximate, and errors accumulate over many updates, so the
+// graph should still be ranked fully from time to time. If the graph has not been
+// ranked, the edge is only linked.
+func (g *Graph64) UpdateRankIncremental(source, target uint64, weight float64) {
+	g.update(source, target, weight, &online64{residuals: make(map[uint]float64)})
+}
+
+// online64 is the push state of an online ranking: the residuals, the nodes whose
+// residual is large enough to be pushed, and the rank leaked by dangling nodes
+// that is not spread yet.
+type online64 struct {
+	residuals map[uint]float64
+	queue     []uint
+	leaked    float64
+}
+
+// AddEdgeOnline links an edge and keeps the ranks returned by Ranks up to date for
+// it, for graphs that grow as a stream of edges: rank the graph once, then add
+// every new edge with AddEdgeOnline instead of Link. The ranks are updated with
+// local pushes like UpdateRankIncremental, but in online mode the residuals too
+// small to be pushed, and those left when the pushes run out, are kept and
+// carried into the next update instead of being dropped, so errors don't pile up
+// along the stream. OnlineResidual reports the residual held back. Ranking the
+// graph again starts afresh.
+func (g *Graph64) AddEdgeOnline(source, target uint64, weight float64) {
+	if g.online == nil {
+		g.online = &online64{residuals: make(map[uint]float64)}
+	}
+	g.update(source, target, weight, g.online)
+}
+
+// OnlineResidual returns the sum of the absolute residuals held back by
+// AddEdgeOnline since the last ranking, a bound on how far the ranks returned by
+// Ranks are from the ones the pushes would converge to.
+func (g *Graph64) OnlineResidual() float64 {
+	if g.online == nil {
+		return 0
+	}
+	sum := math.Abs(g.online.leaked)
+	for _, residual := range g.online.residuals {
+		sum += math.Abs(residual)
+	}
+	return sum
+}
+
+// update links an edge and pushes the change of the ranks it makes as residuals
+// from the state, leaving the residuals it doesn't push in the state.
+func (g *Graph64) update(source, target uint64, weight float64, state *online64) {
+	s, ok := g.index[source]
+	if !g.ranked || !ok {
+		count := len(g.nodes)
+		g.Link(source, target, weight)
+		if g.ranked && len(g.nodes) > count {
+			g.grow(count)
+		}
+		return
+	}
+
+	// The transition probabilities of the source before the edge.
+	g.denormalize()
+	node := &g.nodes[s]
+	dangling := node.dangling()
+	before := make(map[uint]float64, len(node.edges))
+	if !dangling {
+		for t, w := range node.edges {
+			before[t] = w / node.outbound
+		}
+	}
+
+	count := len(g.nodes)
+	if g.LinkChecked(source, target, weight) != nil {
+		g.Rejected++
+		return
+	}
+	slot, α := g.slot, g.alpha(s, g.rankedAlpha)
+	if len(g.nodes) > count {
+		g.grow(count)
+	}
+	nodes := g.nodes
+	node = &nodes[s]
+	flow := α * node.weight[slot]
+
+	changes := make(map[uint]float64)
+	if !node.dangling() {
+		for t, w := range node.edges {
+			changes[t] += flow * w / node.outbound
+		}
+	}
+	for t, p := range before {
+		changes[t] -= flow * p
+	}
+	// The rank of a dangling source leaked to every node before, or does now.
+	if dangling != node.dangling() {
+		if node.dangling() {
+			state.leaked += flow
+		} else {
+			state.leaked -= flow
+		}
+	}
+
+	threshold := 1e-3 / float64(len(nodes))
+	residuals := state.residuals
+	small := func(residual float64) bool {
+		return residual < threshold && residual > -threshold
+	}
+	for t, change := range changes {
+		previous := residuals[t]
+		residuals[t] = previous + change
+		if small(previous) {
+			state.queue = append(state.queue, t)
+		}
+	}
+	for pushes := 0; pushes < incrementalPushes; pushes++ {
+		if len(state.queue) == 0 {
+			// The leaked rank is pushed from every node once the rest is.
+			if small(state.leaked) {
+				break
+			}
+			g.spread(state.leaked, residuals)
+			for t := range residuals {
+				state.queue = append(state.queue, t)
+			}
+			state.leaked = 0
+		}
+		i := state.queue[0]
+		state.queue = state.queue[1:]
+		residual := residuals[i]
+		if small(residual) {
+			continue
+		}
+		delete(residuals, i)
+		nodes[i].weight[slot] += residual
+
+		α := g.alpha(i, g.rankedAlpha)
+		if nodes[i].dangling() {
+			state.leaked += α * residual
+			continue
+		}
+		for t, w := range nodes[i].edges {
+			previous := residuals[t]
+			residuals[t] = previous + α*residual*w/nodes[i].outbound
+			if small(previous) {
+				state.queue = append(state.queue, t)
+			}
+		}
+	}
+}
+
+// spread adds rank to the residual of every node following the teleport
+// distribution.
+func (g *Graph64) spread(rank float64, residuals map[uint]float64) {
+	teleport := g.teleport(nil)
+	inverse := 1 / float64(len(g.nodes))
+	for i := range g.nodes {
+		if teleport != nil {
+			residuals[uint(i)] += rank * teleport[i]
+		} else {
+			residuals[uint(i)] += rank * inverse
+		}
+	}
+}
+
+// grow gives the nodes added to a ranked graph after the first count their share
+// of the teleport, and rescales the ranks to sum to 1.
+func (g *Graph64) grow(count int) {
+	nodes, slot := g.nodes, g.slot
+	inverse := 1 / float64(len(nodes))
+	total := float64(0)
+	for i := range nodes {
+		if i >= count {
+			nodes[i].weight[slot] = (1 - g.rankedAlpha) * inverse
+			nodes[i].weight[1-slot] = 0
+		}
+		total += nodes[i].weight[slot]
+	}
+	for i := range nodes {
+		nodes[i].weight[slot] /= total
+	}
+}
@@ -0,0 +1,71 @@
+package pagerank
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUpdateRankIncremental64(t *testing.T) {
+	graph := scaleFree64(2000, 3)
+	if ranks := graph.Ranks(); ranks != nil {
+		t.Error("Expected no ranks before ranking but got", len(ranks))
+	}
+	graph.Rank(0.85, 0.000000001, func(node uint64, rank float64) {})
+	stale := graph.Ranks()
+
+	graph.UpdateRankIncremental(5, 1999, 1.0)
+	graph.UpdateRankIncremental(1, 1500, 10.0)
+	graph.UpdateRankIncremental(1500, 2000, 1.0)
+	incremental := graph.Ranks()
+
+	expected := map[uint64]float64{}
+	graph.Rank(0.85, 0.000000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	distance := func(ranks map[uint64]float64) float64 {
+		sum := float64(0)
+		for id, rank := range expected {
+			sum += math.Abs(ranks[id] - rank)
+		}
+		return sum
+	}
+	before, after := distance(stale), distance(incremental)
+	if after > before/10 {
+		t.Error("Expected the incremental update to reduce the error but it went from", before, "to", after)
+	}
+}
+
+func TestAddEdgeOnline64(t *testing.T) {
+	graph := NewGraph64()
+	graph.AddEdgeOnline(1, 2, 1.0)
+	if ranks := graph.Ranks(); ranks != nil {
+		t.Error("Expected no ranks before ranking but got", ranks)
+	}
+	graph.AddEdgeOnline(2, 3, 1.0)
+	graph.AddEdgeOnline(3, 1, 1.0)
+	graph.AddNode(4)
+	graph.Rank(0.85, 0.000000001, func(node uint64, rank float64) {})
+
+	graph.AddEdgeOnline(1, 3, 1.0)
+	graph.AddEdgeOnline(3, 4, 2.0)
+	graph.AddEdgeOnline(4, 1, 1.0)
+	online := graph.Ranks()
+	if residual := graph.OnlineResidual(); residual <= 0 || residual > 0.01 {
+		t.Error("Expected a small residual held back but got", residual)
+	}
+
+	expected := map[uint64]float64{}
+	graph.Rank(0.85, 0.000000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+	for id, rank := range expected {
+		if math.Abs(online[id]-rank) > 0.01 {
+			t.Error("Expected", expected, "but got", online)
+			break
+		}
+	}
+	if residual := graph.OnlineResidual(); residual != 0 {
+		t.Error("Expected no residual after ranking but got", residual)
+	}
+}
@@ -0,0 +1,319 @@
+package pagerank
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteGraphJSON writes the nodes and edges of the graph as JSON, in the form
+// {"nodes":[{"id":1},...],"edges":[{"source":1,"target":2,"weight":1},...]}
+// used by force directed layouts. Nodes are sorted by id and edges by source and
+// target, with their weights as linked.
+func (g *Graph64) WriteGraphJSON(w io.Writer) error {
+	type node struct {
+		ID uint64 `json:"id"`
+	}
+	type edge struct {
+		Source uint64  `json:"source"`
+		Target uint64  `json:"target"`
+		Weight float64 `json:"weight"`
+	}
+	graph := struct {
+		Nodes []node `json:"nodes"`
+		Edges []edge `json:"edges"`
+	}{
+		Nodes: make([]node, 0, len(g.nodes)),
+		Edges: make([]edge, 0, g.edges()),
+	}
+
+	ids := g.ids()
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		graph.Nodes = append(graph.Nodes, node{ID: id})
+	}
+	for _, e := range g.sortedEdges() {
+		graph.Edges = append(graph.Edges, edge{Source: e.Source, Target: e.Target, Weight: e.Weight})
+	}
+
+	return json.NewEncoder(w).Encode(graph)
+}
+
+// WriteRanksCSV writes ranks as CSV, a header followed by one id,rank row per
+// node. If sorted is true the rows are sorted by decreasing rank and then by id.
+func WriteRanksCSV(w io.Writer, ranks map[uint64]float64, sorted bool) error {
+	ids := make([]uint64, 0, len(ranks))
+	for id := range ranks {
+		ids = append(ids, id)
+	}
+	if sorted {
+		sort.Slice(ids, func(i, j int) bool {
+			if ranks[ids[i]] != ranks[ids[j]] {
+				return ranks[ids[i]] > ranks[ids[j]]
+			}
+			return ids[i] < ids[j]
+		})
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"id", "rank"}); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		row := []string{
+			strconv.FormatUint(id, 10),
+			strconv.FormatFloat(ranks[id], 'g', -1, 64),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteRankedEdges ranks the graph like Rank and writes every edge as a CSV
+// source,target,flow row after a header row, where flow is the damping factor of
+// the source times its rank times the transition probability of the edge: the
+// rank the source sends along it in an iteration. The edges of dangling nodes
+// carry no flow. Edges are sorted by source and target.
+func (g *Graph64) WriteRankedEdges(w io.Writer, α, ε float64) error {
+	a := g.iterate(&settings64{α: α, ε: ε})
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"source", "target", "flow"}); err != nil {
+		return err
+	}
+	for _, edge := range g.sortedEdges() {
+		s := g.index[edge.Source]
+		source := &g.nodes[s]
+		flow := float64(0)
+		if !source.dangling() {
+			flow = g.alpha(s, α) * source.weight[a] * edge.Weight / source.outbound
+		}
+		row := []string{
+			strconv.FormatUint(edge.Source, 10),
+			strconv.FormatUint(edge.Target, 10),
+			strconv.FormatFloat(flow, 'g', -1, 64),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// maxInt is the largest int.
+const maxInt = uint64(^uint(0) >> 1)
+
+// matrixMarketIsolated bounds the number of nodes without entries that
+// LoadMatrixMarket registers, so that a corrupt size line can't exhaust memory.
+const matrixMarketIsolated = 1 << 20
+
+// LoadMatrixMarket reads a graph from a sparse matrix in the Matrix Market
+// coordinate format, with real, integer or pattern values and general or
+// symmetric symmetry. Entry i j v of the matrix becomes an edge from node i to
+// node j weighted by v, or by 1 for pattern matrices; symmetric matrices store
+// one triangle, so every off diagonal entry becomes an edge in both directions.
+// Nodes are identified by their 1 based row and column numbers and every one of
+// them is registered, even without edges. A size with more than 1<<20 such nodes
+// beyond those the entries can link is rejected.
+func LoadMatrixMarket(r io.Reader) (*Graph64, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	next := func() ([]string, bool) {
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text != "" && !strings.HasPrefix(text, "%") {
+				return strings.Fields(text), true
+			}
+		}
+		return nil, false
+	}
+	fail := func(format string, a ...interface{}) error {
+		return fmt.Errorf("pagerank: matrix market line %d: %s", line, fmt.Sprintf(format, a...))
+	}
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fail("missing header")
+	}
+	line++
+	header := strings.Fields(strings.ToLower(scanner.Text()))
+	if len(header) != 5 || header[0] != "%%matrixmarket" || header[1] != "matrix" {
+		return nil, fail("invalid header")
+	}
+	if header[2] != "coordinate" {
+		return nil, fail("unsupported format %s", header[2])
+	}
+	pattern := false
+	switch header[3] {
+	case "real", "integer":
+	case "pattern":
+		pattern = true
+	default:
+		return nil, fail("unsupported field %s", header[3])
+	}
+	symmetric := false
+	switch header[4] {
+	case "general":
+	case "symmetric":
+		symmetric = true
+	default:
+		return nil, fail("unsupported symmetry %s", header[4])
+	}
+
+	fields, ok := next()
+	if !ok {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fail("missing size")
+	}
+	if len(fields) != 3 {
+		return nil, fail("invalid size")
+	}
+	var size [3]uint64
+	for i, field := range fields {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, fail("invalid size: %v", err)
+		}
+		size[i] = value
+	}
+	rows, columns, entries := size[0], size[1], size[2]
+
+	n := rows
+	if columns > n {
+		n = columns
+	}
+	// Every entry links at most two nodes, so the nodes beyond those are isolated
+	// and are only allowed up to matrixMarketIsolated of them.
+	if entries > maxInt/2 || n > maxInt || n > 2*entries+matrixMarketIsolated {
+		return nil, fail("size %d×%d out of proportion to %d entries", rows, columns, entries)
+	}
+	graph := NewGraph64(int(n))
+	for id := uint64(1); id <= n; id++ {
+		graph.AddNode(id)
+	}
+
+	columnsPerEntry := 3
+	if pattern {
+		columnsPerEntry = 2
+	}
+	for k := uint64(0); k < entries; k++ {
+		fields, ok := next()
+		if !ok {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fail("expected %d entries but got %d", entries, k)
+		}
+		if len(fields) != columnsPerEntry {
+			return nil, fail("invalid entry")
+		}
+		i, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil || i < 1 || i > rows {
+			return nil, fail("invalid row %s", fields[0])
+		}
+		j, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil || j < 1 || j > columns {
+			return nil, fail("invalid column %s", fields[1])
+		}
+		weight := 1.0
+		if !pattern {
+			if weight, err = strconv.ParseFloat(fields[2], 64); err != nil {
+				return nil, fail("invalid value: %v", err)
+			}
+		}
+		graph.Link(i, j, weight)
+		if symmetric && i != j {
+			graph.Link(j, i, weight)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// matrixMarketIDs returns the ids of the nodes sorted, which are the rows and
+// columns of the matrix written by WriteMatrixMarket in order.
+func (g *Graph64) matrixMarketIDs() []uint64 {
+	ids := g.ids()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// WriteMatrixMarket writes the row normalized transition matrix of the graph in
+// the Matrix Market real general coordinate format, as read by LoadMatrixMarket,
+// MATLAB or SciPy: entry i j p is the probability p of moving from node i to node
+// j along an edge. Nodes are numbered from 1 in increasing order of their ids,
+// which WriteMatrixMarketIDs writes. Entries are sorted by row and column, and the
+// rows of dangling nodes are empty.
+func (g *Graph64) WriteMatrixMarket(w io.Writer) error {
+	g.normalize()
+	ids := g.matrixMarketIDs()
+	numbers := make([]int, len(g.nodes))
+	for i, id := range ids {
+		numbers[g.index[id]] = i + 1
+	}
+
+	entries := 0
+	for i := range g.nodes {
+		if !g.nodes[i].dangling() {
+			entries += len(g.nodes[i].edges)
+		}
+	}
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "%%MatrixMarket matrix coordinate real general")
+	fmt.Fprintln(out, len(ids), len(ids), entries)
+	columns := []uint{}
+	for i, id := range ids {
+		node := &g.nodes[g.index[id]]
+		if node.dangling() {
+			continue
+		}
+		columns = columns[:0]
+		for target := range node.edges {
+			columns = append(columns, target)
+		}
+		sort.Slice(columns, func(i, j int) bool { return numbers[columns[i]] < numbers[columns[j]] })
+		for _, target := range columns {
+			fmt.Fprintln(out, i+1, numbers[target], strconv.FormatFloat(node.edges[target], 'g', -1, 64))
+		}
+	}
+	return out.Flush()
+}
+
+// WriteMatrixMarketIDs writes the id of every row and column of the matrix
+// written by WriteMatrixMarket as a CSV index,id row, after a header.
+func (g *Graph64) WriteMatrixMarketIDs(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"index", "id"}); err != nil {
+		return err
+	}
+	for i, id := range g.matrixMarketIDs() {
+		row := []string{
+			strconv.Itoa(i + 1),
+			strconv.FormatUint(id, 10),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
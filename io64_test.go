@@ -0,0 +1,208 @@
+package pagerank
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriteGraphJSON64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(2, 3, 3.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(1, 2, 1.0)
+	graph.AddNode(4)
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+
+	var buffer bytes.Buffer
+	if err := graph.WriteGraphJSON(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"nodes":[{"id":1},{"id":2},{"id":3},{"id":4}],"edges":[` +
+		`{"source":1,"target":2,"weight":1},{"source":1,"target":3,"weight":2},` +
+		`{"source":2,"target":3,"weight":3}]}` + "\n"
+	if actual := buffer.String(); actual != expected {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestWriteRanksCSV64(t *testing.T) {
+	ranks := map[uint64]float64{
+		1: 0.25,
+		2: 0.5,
+		3: 0.125,
+		4: 0.125,
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteRanksCSV(&buffer, ranks, true); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,rank\n2,0.5\n1,0.25\n3,0.125\n4,0.125\n"
+	if actual := buffer.String(); actual != expected {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	buffer.Reset()
+	if err := WriteRanksCSV(&buffer, ranks, false); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buffer.String(), "\n"); lines != 5 {
+		t.Error("Expected 5 lines but got", lines)
+	}
+}
+
+func TestWriteRankedEdges64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 3.0)
+	graph.Link(2, 1, 1.0)
+	graph.Link(3, 1, 1.0)
+
+	ranks := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		ranks[node] = rank
+	})
+
+	var buffer bytes.Buffer
+	if err := graph.WriteRankedEdges(&buffer, 0.85, 0.000001); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][3]float64{
+		{1, 2, 0.85 * ranks[1] / 4},
+		{1, 3, 0.85 * ranks[1] * 3 / 4},
+		{2, 1, 0.85 * ranks[2]},
+		{3, 1, 0.85 * ranks[3]},
+	}
+	if len(records) != len(expected)+1 || !reflect.DeepEqual(records[0], []string{"source", "target", "flow"}) {
+		t.Fatal("Expected", expected, "after a header but got", records)
+	}
+	for i, record := range records[1:] {
+		for j, field := range record {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil || math.Abs(value-expected[i][j]) > 1e-9 {
+				t.Error("Expected", expected[i], "but got", record)
+				break
+			}
+		}
+	}
+}
+
+func TestLoadMatrixMarket64(t *testing.T) {
+	general := `%%MatrixMarket matrix coordinate real general
+% the test graph
+4 4 5
+1 2 1.0
+1 3 2.0
+2 3 3.0
+2 4 4.0
+3 1 5.0
+`
+	graph, err := LoadMatrixMarket(strings.NewReader(general))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewGraph64()
+	expected.Link(1, 2, 1.0)
+	expected.Link(1, 3, 2.0)
+	expected.Link(2, 3, 3.0)
+	expected.Link(2, 4, 4.0)
+	expected.Link(3, 1, 5.0)
+	if !graph.Equal(expected) {
+		t.Error("Expected the general matrix to load the test graph")
+	}
+
+	symmetric := `%%MatrixMarket matrix coordinate pattern symmetric
+5 5 3
+2 1
+3 3
+4 2
+`
+	graph, err = LoadMatrixMarket(strings.NewReader(symmetric))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = NewGraph64()
+	expected.Link(2, 1, 1.0)
+	expected.Link(1, 2, 1.0)
+	expected.Link(3, 3, 1.0)
+	expected.Link(4, 2, 1.0)
+	expected.Link(2, 4, 1.0)
+	expected.AddNode(5)
+	if !graph.Equal(expected) {
+		t.Error("Expected the symmetric matrix to load edges in both directions")
+	}
+
+	for _, invalid := range []string{
+		"",
+		"%%MatrixMarket matrix array real general\n2 2\n1\n2\n3\n4\n",
+		"%%MatrixMarket matrix coordinate complex general\n1 1 1\n1 1 1 0\n",
+		"%%MatrixMarket matrix coordinate real general\n2 2 2\n1 2 1\n",
+		"%%MatrixMarket matrix coordinate real general\n2 2 1\n3 1 1\n",
+		"%%MatrixMarket matrix coordinate real general\n1 18446744073709551615 0\n",
+		"%%MatrixMarket matrix coordinate real general\n100000000 100000000 1\n1 2 1\n",
+	} {
+		if _, err := LoadMatrixMarket(strings.NewReader(invalid)); err == nil {
+			t.Error("Expected an error for", invalid)
+		}
+	}
+}
+
+func TestWriteMatrixMarket64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(30, 10, 1.0)
+	graph.Link(30, 20, 3.0)
+	graph.Link(10, 30, 2.0)
+	graph.Link(20, 40, 1.0)
+
+	matrix := bytes.Buffer{}
+	if err := graph.WriteMatrixMarket(&matrix); err != nil {
+		t.Fatal(err)
+	}
+	expected := "%%MatrixMarket matrix coordinate real general\n" +
+		"4 4 4\n" +
+		"1 3 1\n" +
+		"2 4 1\n" +
+		"3 1 0.25\n" +
+		"3 2 0.75\n"
+	if matrix.String() != expected {
+		t.Error("Expected", expected, "but got", matrix.String())
+	}
+
+	ids := bytes.Buffer{}
+	if err := graph.WriteMatrixMarketIDs(&ids); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "index,id\n1,10\n2,20\n3,30\n4,40\n"; ids.String() != expected {
+		t.Error("Expected", expected, "but got", ids.String())
+	}
+
+	loaded, err := LoadMatrixMarket(&matrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRanks, actual := map[uint64]float64{}, map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expectedRanks[node] = rank
+	})
+	loaded.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[10*node] = rank
+	})
+	for id, rank := range expectedRanks {
+		if math.Abs(actual[id]-rank) > 0.000001 {
+			t.Error("Expected", expectedRanks, "but got", actual)
+			break
+		}
+	}
+}
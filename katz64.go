@@ -0,0 +1,109 @@
+package pagerank
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrDiverged is returned when Katz centrality doesn't converge because the
+// attenuation is not below the reciprocal of the spectral radius
+var ErrDiverged = errors.New("pagerank: diverged")
+
+// katzWindow is the number of iterations over which the growth of Δ is examined
+// to detect that Katz centrality diverges.
+const katzWindow = 16
+
+// Katz computes the Katz centrality of every node: the number of walks ending at
+// the node, each walk of length k counted with the product of the weights of its
+// edges times attenuation^k, the empty walk included. Unlike Rank the edge
+// weights are not normalized, so a node linking to many others gives full weight
+// to each of them. The centrality is computed by iterating x = attenuation·Aᵀx + 1
+// until Δ, the L1 change of x, falls below ε.
+//
+// The series only converges if attenuation is below the reciprocal of the
+// spectral radius of the weighted adjacency matrix. Δ can grow for a while on
+// graphs where it converges, such as dense acyclic graphs, so divergence is only
+// detected when Δ is not finite, when Δ grew at every one of the last katzWindow
+// iterations without the growth slowing down, when Δ hasn't reached a new minimum
+// for katzWindow iterations more than there are nodes, since longer walks have to
+// go around cycles, or when MaxIterations is reached. Nothing is ranked then, and
+// ErrDiverged is returned.
+func (g *Graph64) Katz(attenuation, ε float64, callback func(id uint64, rank float64)) error {
+	g.dequantize()
+	nodes := g.nodes
+	n := len(nodes)
+	x, next := make([]float64, n), make([]float64, n)
+	for i := range x {
+		x[i] = 1
+	}
+
+	Δ, lowest, stalled, iterations := math.Inf(1), math.Inf(1), 0, 0
+	// deltas holds the Δ of the last katzWindow+1 iterations.
+	deltas := make([]float64, 0, katzWindow+1)
+	diverged := func(reason string) error {
+		g.LastRun = Run64{
+			Iterations: iterations,
+			Delta:      Δ,
+			Reason:     reason,
+		}
+		return ErrDiverged
+	}
+	for Δ > ε {
+		if g.MaxIterations > 0 && iterations >= g.MaxIterations {
+			return diverged(ReasonMaxIterations)
+		}
+		for i := range next {
+			next[i] = 1
+		}
+		for source := range nodes {
+			node := &nodes[source]
+			for target, weight := range node.edges {
+				next[target] += attenuation * g.raw(node, weight) * x[source]
+			}
+		}
+		Δ = 0
+		for i := range next {
+			Δ += math.Abs(next[i] - x[i])
+		}
+		x, next = next, x
+		iterations++
+
+		if !finite(Δ) {
+			return diverged(ReasonStalled)
+		}
+		if len(deltas) == cap(deltas) {
+			deltas = append(deltas[:0], deltas[1:]...)
+		}
+		deltas = append(deltas, Δ)
+		if len(deltas) == cap(deltas) {
+			growing := true
+			for i := 1; i < len(deltas); i++ {
+				if !(deltas[i] > deltas[i-1]) {
+					growing = false
+					break
+				}
+			}
+			half := katzWindow / 2
+			early, late := deltas[half]/deltas[0], deltas[katzWindow]/deltas[half]
+			if growing && late >= early {
+				return diverged(ReasonStalled)
+			}
+		}
+		if Δ < lowest {
+			lowest, stalled = Δ, 0
+		} else if stalled++; stalled >= katzWindow+n {
+			return diverged(ReasonStalled)
+		}
+	}
+	g.LastRun = Run64{
+		Iterations: iterations,
+		Delta:      Δ,
+		Converged:  true,
+		Reason:     ReasonConverged,
+	}
+
+	for key, value := range g.index {
+		callback(key, x[value])
+	}
+	return nil
+}
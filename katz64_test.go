@@ -0,0 +1,72 @@
+package pagerank
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestKatz64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 2.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(1, 3, 1.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 1,
+		2: 2,
+		3: 2.5,
+	}
+
+	err := graph.Katz(0.5, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	graph.Link(3, 1, 1.0)
+	err = graph.Katz(0.9, 0.000001, func(node uint64, rank float64) {
+		t.Error("Expected nothing to be ranked")
+	})
+	if err != ErrDiverged {
+		t.Error("Expected", ErrDiverged, "but got", err)
+	}
+}
+
+func TestKatzAcyclic64(t *testing.T) {
+	graph := NewGraph64()
+	for i := uint64(0); i < 40; i++ {
+		for j := i + 1; j < 40; j++ {
+			graph.Link(i, j, 1.0)
+		}
+	}
+
+	// Node j is reached by the walks from every subset of the nodes before it.
+	actual := map[uint64]float64{}
+	err := graph.Katz(0.5, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if err != nil {
+		t.Fatal("Expected the series to converge but got", err, graph.LastRun)
+	}
+	for node, rank := range actual {
+		if expected := math.Pow(1.5, float64(node)); math.Abs(rank-expected) > 0.000001*expected {
+			t.Error("Expected", expected, "for", node, "but got", rank)
+		}
+	}
+
+	graph.MaxIterations = 10
+	err = graph.Katz(0.5, 0.000001, func(node uint64, rank float64) {
+		t.Error("Expected nothing to be ranked")
+	})
+	if err != ErrDiverged || graph.LastRun.Reason != ReasonMaxIterations {
+		t.Error("Expected", ErrDiverged, "at MaxIterations but got", err, graph.LastRun)
+	}
+}
@@ -0,0 +1,71 @@
+package pagerank
+
+// LayerID identifies a layer of edge weights, such as a time window.
+type LayerID uint32
+
+// layeredEdge64 is an edge of a given layer between two internal indices.
+type layeredEdge64 struct {
+	source, target uint
+	layer          LayerID
+}
+
+// LinkLayer creates a weighted edge like Link, and records its weight in the given
+// layer for RankLayers. The weights of the layers add up for Rank. Graphs linked
+// only with Link don't store any layer. Like for LinkTyped, the weight recorded is
+// the one the graph stores, and nothing is recorded for edges that are rejected
+// or evicted.
+func (g *Graph64) LinkLayer(source, target uint64, weight float64, layer LayerID) {
+	s, t, stored, scale, changed, err := g.linkStored(source, target, weight)
+	if err != nil {
+		g.Rejected++
+		return
+	}
+	if changed {
+		edges := g.nodes[s].edges
+		for edge, weight := range g.layers {
+			if edge.source != s {
+				continue
+			}
+			if _, ok := edges[edge.target]; ok {
+				g.layers[edge] = weight * scale
+			} else {
+				delete(g.layers, edge)
+			}
+		}
+	}
+	if _, ok := g.nodes[s].edges[t]; !ok {
+		return
+	}
+	if g.layers == nil {
+		g.layers = make(map[layeredEdge64]float64)
+	}
+	g.layers[layeredEdge64{source: s, target: t, layer: layer}] += stored
+}
+
+// RankLayers computes the PageRank of every node like Rank, over the edges linked
+// by LinkLayer with their weights combined across the layers: the weight of an
+// edge is the sum of its weight in every layer times the coefficient of the layer
+// in weights. Layers missing from weights count for nothing, and edges whose
+// combined weight is not positive are left out. The ranking runs on a combined
+// copy of the graph, with the teleport distribution and other ranking settings of
+// the graph, so the graph itself is left as is.
+func (g *Graph64) RankLayers(weights map[LayerID]float64, α, ε float64, callback func(id uint64, rank float64)) {
+	type pair struct {
+		source, target uint
+	}
+	combined := make(map[pair]float64)
+	for edge, weight := range g.layers {
+		if coefficient, ok := weights[edge.layer]; ok {
+			combined[pair{edge.source, edge.target}] += coefficient * weight
+		}
+	}
+
+	ids := g.ids()
+	g.rankView(func(view *Graph64) {
+		for edge, weight := range combined {
+			if weight > 0 {
+				view.Link(ids[edge.source], ids[edge.target], weight)
+			}
+		}
+	}, α, ε, callback)
+}
@@ -0,0 +1,82 @@
+package pagerank
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRankLayers64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.LinkLayer(1, 2, 1.0, 0)
+	graph.LinkLayer(1, 3, 1.0, 0)
+	graph.LinkLayer(2, 3, 1.0, 0)
+	graph.LinkLayer(3, 1, 2.0, 0)
+	graph.LinkLayer(1, 2, 2.0, 1)
+	graph.LinkLayer(2, 4, 4.0, 1)
+	graph.LinkLayer(3, 4, 4.0, 1)
+	graph.LinkLayer(4, 1, 2.0, 1)
+
+	expected := map[uint64]float64{}
+	single := NewGraph64()
+	single.Link(1, 2, 2.0)
+	single.Link(1, 3, 2.0)
+	single.Link(2, 3, 2.0)
+	single.Link(3, 1, 4.0)
+	single.Link(1, 2, 1.0)
+	single.Link(2, 4, 2.0)
+	single.Link(3, 4, 2.0)
+	single.Link(4, 1, 1.0)
+	single.SetTeleport(map[uint64]float64{2: 1, 4: 1})
+	single.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	actual := map[uint64]float64{}
+	graph.SetTeleport(map[uint64]float64{2: 1, 4: 1})
+	graph.RankLayers(map[LayerID]float64{0: 2, 1: 0.5}, 0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	actual = map[uint64]float64{}
+	graph.RankLayers(map[LayerID]float64{0: 1, 1: -1}, 0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if actual[4] >= actual[1] || actual[4] >= actual[3] {
+		t.Error("Expected node 4 to be unreachable but got", actual)
+	}
+}
+
+func TestLinkLayerStored64(t *testing.T) {
+	for _, setup := range []func(graph *Graph64){
+		func(graph *Graph64) { graph.LogSpace = true },
+		func(graph *Graph64) { graph.MaxEdgesPerNode = 2 },
+	} {
+		graph := NewGraph64()
+		setup(graph)
+
+		graph.LinkLayer(1, 2, 1.0, 0)
+		graph.LinkLayer(1, 3, 2.0, 1)
+		graph.LinkLayer(1, 4, 3.0, 0)
+		graph.LinkLayer(1, 3, 1.0, 0)
+		graph.LinkLayer(2, 1, 1.0, 1)
+		graph.LinkLayer(3, 1, 5.0, 0)
+		graph.LinkLayer(4, 2, 2.0, 1)
+
+		expected := map[uint64]float64{}
+		graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+			expected[node] = rank
+		})
+		actual := map[uint64]float64{}
+		graph.RankLayers(map[LayerID]float64{0: 1, 1: 1}, 0.85, 0.000001, func(node uint64, rank float64) {
+			actual[node] = rank
+		})
+
+		if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+			t.Error("Expected", expected, "but got", actual)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package pagerank
+
+import "sort"
+
+// TransitionMatrix returns the row normalized transition matrix of the graph, as
+// a dense slice of rows, along with the id of the node of every row and column.
+// The rows of dangling nodes are zero. The matrix can be passed on to a linear
+// algebra package, for example with gonum's mat.NewDense.
+func (g *Graph64) TransitionMatrix() ([][]float64, []uint64) {
+	g.normalize()
+
+	n := len(g.nodes)
+	matrix := make([][]float64, n)
+	for source := range g.nodes {
+		matrix[source] = make([]float64, n)
+		if g.nodes[source].dangling() {
+			continue
+		}
+		for target, weight := range g.nodes[source].edges {
+			matrix[source][target] = weight
+		}
+	}
+	return matrix, g.ids()
+}
+
+// Laplacian returns the random walk Laplacian L = I - P of the graph, where P is
+// the transition matrix returned by TransitionMatrix, as a dense slice of rows,
+// along with the id of the node of every row and column. The rows of dangling
+// nodes are those of the identity. The eigenvalues of L are 1 minus those of P,
+// so spectral methods can work on either.
+func (g *Graph64) Laplacian() ([][]float64, []uint64) {
+	matrix, ids := g.TransitionMatrix()
+	for i, row := range matrix {
+		for j := range row {
+			if row[j] != 0 {
+				row[j] = -row[j]
+			}
+		}
+		row[i]++
+	}
+	return matrix, ids
+}
+
+// TransitionSparse returns the row normalized transition matrix of the graph in
+// compressed sparse row format: the entries of row i are columns[offsets[i]:offsets[i+1]]
+// and values[offsets[i]:offsets[i+1]], sorted by column. ids holds the id of the
+// node of every row and column. The rows of dangling nodes are empty.
+func (g *Graph64) TransitionSparse() (offsets, columns []int, values []float64, ids []uint64) {
+	g.normalize()
+
+	offsets = make([]int, len(g.nodes)+1)
+	edges := g.edges()
+	columns, values = make([]int, 0, edges), make([]float64, 0, edges)
+	for source := range g.nodes {
+		node := &g.nodes[source]
+		if node.dangling() {
+			offsets[source+1] = len(columns)
+			continue
+		}
+		start := len(columns)
+		for target := range node.edges {
+			columns = append(columns, int(target))
+		}
+		row := columns[start:]
+		sort.Ints(row)
+		for _, target := range row {
+			values = append(values, node.edges[uint(target)])
+		}
+		offsets[source+1] = len(columns)
+	}
+	return offsets, columns, values, g.ids()
+}
+
+// TransitionProbability returns the probability P(source→target) that the random
+// surfer follows the edge from source to target, its weight divided by the
+// outbound weight of source, and whether the edge exists. The graph is normalized
+// first, like by Rank, so the probability is the one used by the rankings. The
+// edges of dangling nodes have a probability of 0, as their rank leaks instead.
+func (g *Graph64) TransitionProbability(source, target uint64) (float64, bool) {
+	s, ok := g.index[source]
+	if !ok {
+		return 0, false
+	}
+	t, ok := g.index[target]
+	if !ok {
+		return 0, false
+	}
+	g.normalize()
+	node := &g.nodes[s]
+	weight, ok := node.edges[t]
+	if !ok || node.dangling() {
+		return 0, ok
+	}
+	return weight, true
+}
@@ -0,0 +1,83 @@
+package pagerank
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTransitionMatrix64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 3.0)
+	graph.Link(2, 3, 2.0)
+
+	matrix, ids := graph.TransitionMatrix()
+	expected := [][]float64{
+		{0, 0.25, 0.75},
+		{0, 0, 1},
+		{0, 0, 0},
+	}
+	if !reflect.DeepEqual(ids, []uint64{1, 2, 3}) {
+		t.Error("Unexpected ids", ids)
+	}
+	if !reflect.DeepEqual(matrix, expected) {
+		t.Error("Expected", expected, "but got", matrix)
+	}
+
+	offsets, columns, values, ids := graph.TransitionSparse()
+	if !reflect.DeepEqual(ids, []uint64{1, 2, 3}) {
+		t.Error("Unexpected ids", ids)
+	}
+	if !reflect.DeepEqual(offsets, []int{0, 2, 3, 3}) {
+		t.Error("Unexpected offsets", offsets)
+	}
+	if !reflect.DeepEqual(columns, []int{1, 2, 2}) {
+		t.Error("Unexpected columns", columns)
+	}
+	if !reflect.DeepEqual(values, []float64{0.25, 0.75, 1}) {
+		t.Error("Unexpected values", values)
+	}
+
+	laplacian, _ := graph.Laplacian()
+	expected = [][]float64{
+		{1, -0.25, -0.75},
+		{0, 1, -1},
+		{0, 0, 1},
+	}
+	if !reflect.DeepEqual(laplacian, expected) {
+		t.Error("Expected", expected, "but got", laplacian)
+	}
+
+	graph.Link(3, 1, 0x1p-1074)
+	matrix, _ = graph.TransitionMatrix()
+	if !reflect.DeepEqual(matrix[2], []float64{0, 0, 0}) {
+		t.Error("Expected a zero row for a subnormal outbound weight but got", matrix[2])
+	}
+	offsets, _, _, _ = graph.TransitionSparse()
+	if !reflect.DeepEqual(offsets, []int{0, 2, 3, 3}) {
+		t.Error("Expected an empty row for a subnormal outbound weight but got", offsets)
+	}
+}
+
+func TestTransitionProbability64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 3.0)
+	graph.Link(2, 3, 1.0)
+
+	if p, ok := graph.TransitionProbability(1, 3); !ok || p != 0.75 {
+		t.Error("Expected 0.75 but got", p, ok)
+	}
+	if p, ok := graph.TransitionProbability(3, 1); ok || p != 0 {
+		t.Error("Expected no edge but got", p, ok)
+	}
+	if p, ok := graph.TransitionProbability(4, 1); ok || p != 0 {
+		t.Error("Expected no node but got", p, ok)
+	}
+
+	graph.Link(1, 2, 4.0)
+	if p, ok := graph.TransitionProbability(1, 2); !ok || p != 0.625 {
+		t.Error("Expected 0.625 after linking but got", p, ok)
+	}
+}
@@ -22,12 +22,27 @@ type Node32 struct {
 	edges    map[uint]float32
 }
 
+// Run32 describes the outcome of a ranking.
+type Run32 struct {
+	Iterations int
+	Delta      float32
+	Converged  bool
+	Reason     string
+}
+
 // Graph32 holds node and edge data.
 type Graph32 struct {
-	Verbose bool
-	count   uint
-	index   map[uint64]uint
-	nodes   []Node32
+	Verbose  bool
+	LastRun  Run32
+	Rejected int
+	// MaxIterations, when positive, caps the number of iterations of Rank.
+	MaxIterations int
+	// Progress, when set, is called at the end of every iteration of Rank with
+	// the iteration count and Δ.
+	Progress func(iteration int, Δ float32)
+	count    uint
+	index    map[uint64]uint
+	nodes    []Node32
 }
 
 // NewGraph32 initializes and returns a new graph.
@@ -44,30 +59,57 @@ func NewGraph32(size ...int) *Graph32 {
 
 // Link creates a weighted edge between a source-target node pair.
 // If the edge already exists, the weight is incremented.
+// Edges rejected by LinkChecked are skipped and counted in Rejected.
 func (g *Graph32) Link(source, target uint64, weight float32) {
-	s, ok := g.index[source]
-	if !ok {
-		s = g.count
-		g.index[source] = s
-		g.nodes = append(g.nodes, Node32{})
-		g.count++
+	if g.LinkChecked(source, target, weight) != nil {
+		g.Rejected++
+	}
+}
+
+// LinkChecked is like Link, but returns ErrNonFinite instead of creating an edge
+// whose weight is NaN or infinite, or that would overflow a sum of weights.
+func (g *Graph32) LinkChecked(source, target uint64, weight float32) error {
+	var outbound, edge float32
+	if s, ok := g.index[source]; ok {
+		outbound = g.nodes[s].outbound
+		if t, ok := g.index[target]; ok {
+			edge = g.nodes[s].edges[t]
+		}
 	}
+	if !finite(float64(weight)) || !finite(float64(outbound+weight)) || !finite(float64(edge+weight)) {
+		return ErrNonFinite
+	}
+
+	s := g.add(source)
 
 	g.nodes[s].outbound += weight
 
-	t, ok := g.index[target]
-	if !ok {
-		t = g.count
-		g.index[target] = t
-		g.nodes = append(g.nodes, Node32{})
-		g.count++
-	}
+	t := g.add(target)
 
 	if g.nodes[s].edges == nil {
 		g.nodes[s].edges = map[uint]float32{}
 	}
 
 	g.nodes[s].edges[t] += weight
+	return nil
+}
+
+// AddNode registers a node without any edge, so that it is ranked even if it is
+// never linked. Isolated nodes receive their share of the teleport probability.
+func (g *Graph32) AddNode(id uint64) {
+	g.add(id)
+}
+
+// add returns the index of a node, registering it first if needed.
+func (g *Graph32) add(id uint64) uint {
+	i, ok := g.index[id]
+	if !ok {
+		i = g.count
+		g.index[id] = i
+		g.nodes = append(g.nodes, Node32{})
+		g.count++
+	}
+	return i
 }
 
 // Rank computes the PageRank of every node in the directed graph.
@@ -115,9 +157,10 @@ func (g *Graph32) Rank(α, ε float32, callback func(id uint64, rank float32)) {
 	}
 	leak := float32(0)
 
-	a, b := 0, 1
+	a, b, iterations := 0, 1, 0
 	for source := range nodes {
 		nodes[source].weight[a] = inverse
+		nodes[source].weight[b] = 0
 
 		if nodes[source].outbound == 0 {
 			leak += inverse
@@ -139,7 +182,12 @@ func (g *Graph32) Rank(α, ε float32, callback func(id uint64, rank float32)) {
 		node.Unlock()
 		done <- true
 	}
+	reason := ReasonConverged
 	for Δ > ε {
+		if g.MaxIterations > 0 && iterations >= g.MaxIterations {
+			reason = ReasonMaxIterations
+			break
+		}
 		if g.Verbose {
 			fmt.Println("updating...")
 		}
@@ -181,12 +229,23 @@ func (g *Graph32) Rank(α, ε float32, callback func(id uint64, rank float32)) {
 		}
 
 		a, b = b, a
+		iterations++
+		if g.Progress != nil {
+			g.Progress(iterations, Δ)
+		}
 
 		if g.Verbose {
 			fmt.Println(Δ, ε)
 		}
 	}
 
+	g.LastRun = Run32{
+		Iterations: iterations,
+		Delta:      Δ,
+		Converged:  reason == ReasonConverged,
+		Reason:     reason,
+	}
+
 	for key, value := range g.index {
 		callback(key, nodes[value].weight[a])
 	}
@@ -199,6 +258,8 @@ func (g *Graph32) Reset(size ...int) {
 		capacity = size[0]
 	}
 	g.count = 0
+	g.Rejected = 0
+	g.LastRun = Run32{}
 	g.index = make(map[uint64]uint, capacity)
 	g.nodes = make([]Node32, 0, capacity)
 }
@@ -4,8 +4,25 @@ Package pagerank implements the *weighted* PageRank algorithm.
 package pagerank
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrNonFinite is returned when an edge weight is NaN or infinite, or would
+	// make a sum of weights overflow
+	ErrNonFinite = errors.New("pagerank: non-finite weight")
+	// ErrUnknownNode is returned when an edge links a node that was not
+	// registered with AddNode in StrictNodes mode
+	ErrUnknownNode = errors.New("pagerank: unknown node")
 )
 
 // Node64 is a node in a graph
@@ -14,14 +31,199 @@ type Node64 struct {
 	weight   [2]float64
 	outbound float64
 	edges    map[uint]float64
+	levels   map[uint]uint16
+	scale    float64
+	shift    float64
+}
+
+// move copies the fields of the node, but not its lock, to another node.
+func (n *Node64) move(to *Node64) {
+	if n == to {
+		return
+	}
+	to.weight, to.outbound, to.edges = n.weight, n.outbound, n.edges
+	to.levels, to.scale, to.shift = n.levels, n.scale, n.shift
+}
+
+// minOutbound64 is the smallest outbound weight that edge weights are divided by.
+// Nodes with a smaller outbound weight, such as a subnormal one, are dangling.
+const minOutbound64 = 0x1p-1022
+
+// dangling reports whether the node has no outbound weight to normalize by.
+func (node *Node64) dangling() bool {
+	return node.outbound > -minOutbound64 && node.outbound < minOutbound64
 }
 
+// Run64 describes the outcome of a ranking.
+type Run64 struct {
+	Iterations int
+	Delta      float64
+	Converged  bool
+	Reason     string
+	// Leaked is the fraction of the rank held by dangling nodes at the end of
+	// the run, which they leak to the teleport at every iteration.
+	Leaked float64
+	// TotalLeaked is the rank leaked by dangling nodes summed over every
+	// iteration of the run, the values LeakProgress is called with.
+	TotalLeaked float64
+}
+
+const (
+	// ReasonConverged is the Run64 reason given when Δ fell below ε.
+	ReasonConverged = "converged"
+	// ReasonIterations is the Run64 reason given when a fixed number of
+	// iterations was run without checking for convergence.
+	ReasonIterations = "iterations"
+	// ReasonCancelled is the Run64 reason given when the context of a ranking
+	// was done before it converged.
+	ReasonCancelled = "cancelled"
+	// ReasonMaxIterations is the Run64 reason given when a ranking reached
+	// MaxIterations before it converged.
+	ReasonMaxIterations = "max iterations"
+	// ReasonStalled is the Run64 reason given when Δ stopped decreasing for
+	// StallWindow iterations before it converged.
+	ReasonStalled = "stalled"
+	// ReasonOrderStable is the Run64 reason given when the order of the top
+	// ranked nodes stopped changing, which counts as converged.
+	ReasonOrderStable = "order stable"
+)
+
+// Dangling selects how the rank of dangling nodes, which have no outbound edges,
+// is handled.
+type Dangling int
+
+const (
+	// DanglingLeak redistributes the rank of dangling nodes like teleportation.
+	DanglingLeak Dangling = iota
+	// DanglingSink links dangling nodes to a virtual sink node that links back
+	// uniformly to every node, making the Markov chain fully stochastic without
+	// the leak bookkeeping. The ranks of the real nodes are rescaled to sum to 1,
+	// which gives the same stationary distribution as DanglingLeak.
+	DanglingSink
+)
+
+// Acceleration selects how the convergence of a ranking is accelerated.
+type Acceleration int
+
+const (
+	// AccelerationNone runs plain power iteration.
+	AccelerationNone Acceleration = iota
+	// AccelerationQuadratic applies quadratic extrapolation, the generalization of
+	// Aitken's Δ² process to the rank vector, every extrapolationPeriod
+	// iterations: the last four iterates are assumed to be a combination of the
+	// three dominant eigenvectors, and the two subdominant ones are cancelled.
+	// This cuts the number of iterations by a third or more for α near 1 on web
+	// like graphs; graphs dominated by long cycles gain little.
+	AccelerationQuadratic
+)
+
+// Restart selects how the restart probability set by SetRestart combines with
+// the damping factor of a node.
+type Restart int
+
+const (
+	// RestartScaled scales the damping factor of the node by 1-p: the surfer
+	// restarts with probability p before following the damping factor.
+	RestartScaled Restart = iota
+	// RestartAbsolute makes 1-p the damping factor of the node, replacing α and
+	// the damping factor set by SetDamping.
+	RestartAbsolute
+)
+
+// extrapolationPeriod is the number of iterations between extrapolations.
+const extrapolationPeriod = 10
+
 // Graph64 holds node and edge data.
 type Graph64 struct {
-	Verbose bool
-	count   uint
-	index   map[uint64]uint
-	nodes   []Node64
+	Verbose  bool
+	LastRun  Run64
+	Rejected int
+	// MaxEdgesPerNode, when positive, caps the number of outbound edges of every
+	// node: once a node exceeds it, Link evicts its lowest weighted edge. This
+	// approximates the graph within a memory budget; the evicted weight is removed
+	// from the outbound weight of the node, so its rank flows along its strongest
+	// edges only and the targets of weak edges are underestimated.
+	MaxEdgesPerNode int
+	// MaxEdgeContribution, when positive, caps the rank any single edge carries in
+	// an iteration, α times the rank of its source times its normalized weight,
+	// to resist link farms. The rank clamped off the edges is not lost: like the
+	// rank of dangling nodes, it is redistributed to every node along the teleport
+	// distribution in the same iteration, so the ranks still sum to 1, but strong
+	// edges no longer pass on their source's rank in proportion to their weight.
+	MaxEdgeContribution float64
+	// OnStable, when set, is called during ranking as soon as the rank of a node
+	// changes by less than ε in an iteration, giving progressively finalized
+	// results for large graphs. Every node is reported at most once per ranking;
+	// the rank reported is the one at that iteration, not the converged one.
+	OnStable     func(id uint64, rank float64)
+	DanglingMode Dangling
+	// RestartMode selects how the restart probabilities set by SetRestart apply.
+	RestartMode Restart
+	// Acceleration selects how rankings other than RankFixed are accelerated.
+	Acceleration Acceleration
+	// MaxIterations, when positive, caps the number of iterations of a ranking.
+	MaxIterations int
+	// StallWindow, when positive, stops a ranking once Δ has gone StallWindow
+	// iterations without reaching a new minimum, which happens when the ranks
+	// oscillate or plateau on near periodic graphs instead of converging.
+	StallWindow int
+	// Progress, when set, is called at the end of every iteration of a ranking
+	// with the iteration count and Δ, which is zero for RankFixed.
+	Progress func(iteration int, Δ float64)
+	// LeakProgress, when set, is called at the end of every iteration of a
+	// ranking with the iteration count and the rank held by dangling nodes.
+	LeakProgress func(iteration int, leak float64)
+	// Deterministic makes every node sum its inbound ranks in a fixed order
+	// instead of having the concurrent updates accumulate them in any order, so
+	// that repeated rankings give bit for bit identical results, whatever the
+	// number of CPUs, at some cost in memory and speed.
+	Deterministic bool
+	// Transposed makes every node pull the ranks of its inbound edges instead of
+	// having every node push its rank along its outbound edges, so that nodes
+	// only write their own rank and no locking is needed. The inbound edges are
+	// built on the first ranking and kept until the graph is modified, which
+	// doubles the memory used by edges.
+	Transposed bool
+	// LogSpace makes Link take the logarithm of the edge weights, which are
+	// combined with log-sum-exp. The weights are stored relative to the largest
+	// one of every node, so that very negative log weights don't underflow. It
+	// must be set before linking.
+	LogSpace bool
+	// StrictNodes makes Link reject, and LinkChecked return ErrUnknownNode for,
+	// edges between nodes that were not registered with AddNode first, which
+	// catches mistyped ids in graphs whose node set is fixed.
+	StrictNodes bool
+	// MinRank, when positive, is a floor on the rank of every node, enforced
+	// after convergence: the nodes ranked below it are raised to it and the
+	// ranks of the others are scaled down by a common factor, which is lowered
+	// until no node falls below the floor. The ranks still sum to 1 and keep
+	// their order. If MinRank is 1/n or more for n nodes, the ranks are uniform.
+	MinRank float64
+
+	mutex      sync.Mutex
+	count      uint
+	index      map[uint64]uint
+	nodes      []Node64
+	normalized bool
+	transpose  [][]link64
+	quantized  bool
+	pins       map[uint]float64
+	noTeleport map[uint]bool
+	preference map[uint64]float64
+	preferred  []float64
+	// ranked is set when the ranks of the last ranking, with damping factor
+	// rankedAlpha, are still held in slot of the node weights.
+	ranked      bool
+	slot        int
+	rankedAlpha float64
+	damping     map[uint]float64
+	history     *bufio.Writer
+	timestamps  map[uint]int64
+	typed       map[typedEdge64]float64
+	layers      map[layeredEdge64]float64
+	constraints map[uint]Constraint
+	restart     map[uint]float64
+	online      *online64
 }
 
 // NewGraph64 initializes and returns a new graph.
@@ -38,30 +240,149 @@ func NewGraph64(size ...int) *Graph64 {
 
 // Link creates a weighted edge between a source-target node pair.
 // If the edge already exists, the weight is incremented.
+// Edges rejected by LinkChecked are skipped and counted in Rejected.
 func (g *Graph64) Link(source, target uint64, weight float64) {
-	s, ok := g.index[source]
-	if !ok {
-		s = g.count
-		g.index[source] = s
-		g.nodes = append(g.nodes, Node64{})
-		g.count++
+	if g.LinkChecked(source, target, weight) != nil {
+		g.Rejected++
 	}
+}
 
-	g.nodes[s].outbound += weight
+// LinkChecked is like Link, but returns ErrNonFinite instead of creating an edge
+// whose weight is NaN or infinite, or that would overflow a sum of weights, and
+// ErrUnknownNode instead of creating a node in StrictNodes mode.
+func (g *Graph64) LinkChecked(source, target uint64, weight float64) error {
+	if g.StrictNodes {
+		if _, ok := g.index[source]; !ok {
+			return ErrUnknownNode
+		}
+		if _, ok := g.index[target]; !ok {
+			return ErrUnknownNode
+		}
+	}
+	g.denormalize()
 
-	t, ok := g.index[target]
-	if !ok {
-		t = g.count
-		g.index[target] = t
-		g.nodes = append(g.nodes, Node64{})
-		g.count++
+	var outbound, edge float64
+	if s, ok := g.index[source]; ok {
+		outbound = g.nodes[s].outbound
+		if t, ok := g.index[target]; ok {
+			edge = g.nodes[s].edges[t]
+		}
 	}
+	if g.LogSpace && finite(weight) {
+		// The log weight becomes a weight relative to the other edges of the source.
+		node := &g.nodes[g.add(source)]
+		weight, outbound = node.exponentiate(weight), node.outbound
+	}
+	if !finite(weight) || !finite(outbound+weight) || !finite(edge+weight) {
+		return ErrNonFinite
+	}
+
+	s := g.add(source)
+
+	g.nodes[s].outbound += weight
+
+	t := g.add(target)
 
 	if g.nodes[s].edges == nil {
 		g.nodes[s].edges = map[uint]float64{}
 	}
 
 	g.nodes[s].edges[t] += weight
+	if g.MaxEdgesPerNode > 0 && len(g.nodes[s].edges) > g.MaxEdgesPerNode {
+		g.evict(&g.nodes[s])
+	}
+	return nil
+}
+
+// exponentiate converts a log weight to a weight relative to the largest log
+// weight linked from the node, rescaling the edges of the node when the log
+// weight is the new largest, so that summing the weights is a log-sum-exp.
+func (node *Node64) exponentiate(weight float64) float64 {
+	if node.outbound == 0 {
+		node.shift = weight
+	} else if weight > node.shift {
+		scale := math.Exp(node.shift - weight)
+		for target := range node.edges {
+			node.edges[target] *= scale
+		}
+		node.outbound *= scale
+		node.shift = weight
+	}
+	return math.Exp(weight - node.shift)
+}
+
+// evict removes the lowest weighted edge of a node, the most recent node first
+// among equal weights.
+func (g *Graph64) evict(node *Node64) {
+	lowest, minimum := uint(0), math.Inf(1)
+	for target, weight := range node.edges {
+		if weight < minimum || (weight == minimum && target > lowest) {
+			lowest, minimum = target, weight
+		}
+	}
+	delete(node.edges, lowest)
+	node.outbound -= minimum
+}
+
+// LinkIndexed is like Link, but returns the internal indices of the source and
+// target nodes, which are registered even if the edge is rejected. It is safe for
+// concurrent use with other calls to LinkIndexed, which lets parallel arrays keyed
+// by internal index be populated during ingestion.
+func (g *Graph64) LinkIndexed(source, target uint64, weight float64) (srcIdx, dstIdx uint) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.Link(source, target, weight)
+	return g.add(source), g.add(target)
+}
+
+// finite reports whether x is neither NaN nor infinite.
+func finite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
+
+// LinkAsym creates a pair of edges between a and b in one call: a→b weighted by
+// forward and b→a weighted by back. A zero weight skips that direction.
+// Each edge is normalized against the outbound weight of its own source, so the
+// two weights are independent of each other: forward is relative to the other
+// edges of a, and back to the other edges of b.
+func (g *Graph64) LinkAsym(a, b uint64, forward, back float64) {
+	if forward != 0 {
+		g.Link(a, b, forward)
+	}
+	if back != 0 {
+		g.Link(b, a, back)
+	}
+}
+
+// AddNode registers a node without any edge, so that it is ranked even if it is
+// never linked. Isolated nodes receive their share of the teleport probability.
+func (g *Graph64) AddNode(id uint64) {
+	g.add(id)
+}
+
+// add returns the index of a node, registering it first if needed.
+func (g *Graph64) add(id uint64) uint {
+	i, ok := g.index[id]
+	if !ok {
+		i = g.count
+		g.index[id] = i
+		g.nodes = append(g.nodes, Node64{})
+		g.count++
+		g.transpose = nil
+	}
+	return i
+}
+
+// Pin holds the rank of a node at a fixed value during Rank.
+// The pinned node still propagates its rank along its outbound edges, but its own
+// rank is reset to the pinned value after every iteration. Ranks are not
+// renormalized, so they only sum to 1 if the pinned values are consistent.
+func (g *Graph64) Pin(id uint64, rank float64) {
+	if g.pins == nil {
+		g.pins = make(map[uint]float64)
+	}
+	g.pins[g.add(id)] = rank
 }
 
 // Rank computes the PageRank of every node in the directed graph.
@@ -70,129 +391,1679 @@ func (g *Graph64) Link(source, target uint64, weight float64) {
 //
 // This method will run as many iterations as needed, until the graph converges.
 func (g *Graph64) Rank(α, ε float64, callback func(id uint64, rank float64)) {
-	Δ := float64(1.0)
-	nodes := g.nodes
-	inverse := 1 / float64(len(nodes))
+	a := g.rank(α, ε)
 
-	// Normalize all the edge weights so that their sum amounts to 1.
-	if g.Verbose {
-		fmt.Println("normalize...")
+	for key, value := range g.index {
+		callback(key, g.nodes[value].weight[a])
 	}
-	done := make(chan bool, 8)
-	normalize := func(node *Node64) {
-		if outbound := node.outbound; outbound > 0 {
-			for target := range node.edges {
-				node.edges[target] /= outbound
+}
+
+// RankFixed computes the PageRank of every node by running exactly the given number
+// of iterations. Δ is never computed, which trades accuracy for a predictable and
+// lower latency.
+func (g *Graph64) RankFixed(α float64, iterations int, callback func(id uint64, rank float64)) {
+	a := g.iterate(&settings64{α: α, fixed: true, iterations: iterations})
+
+	for key, value := range g.index {
+		callback(key, g.nodes[value].weight[a])
+	}
+}
+
+// RankResumable computes the PageRank of every node like Rank, but supports
+// resuming after a crash. Every checkpointEvery iterations checkpoint is called
+// with the iteration count and the current ranks, indexed by internal index; the
+// slice is reused between calls, so it has to be copied or persisted before
+// returning. When resumeFrom holds one rank per node, as previously passed to
+// checkpoint, the iteration starts from it instead of the uniform distribution.
+// The rank held by the dangling nodes in resumeFrom can optionally be given as
+// leak, like for RankWarmVector, to skip computing it.
+func (g *Graph64) RankResumable(α, ε float64, checkpointEvery int, checkpoint func(iter int, weights []float64),
+	resumeFrom []float64, callback func(id uint64, rank float64), leak ...float64) {
+	s := settings64{α: α, ε: ε}
+	if len(resumeFrom) == len(g.nodes) {
+		s.initial = resumeFrom
+		if len(leak) == 1 {
+			s.leak = &leak[0]
+		}
+	}
+	if checkpointEvery > 0 && checkpoint != nil {
+		weights := make([]float64, len(g.nodes))
+		s.after = func(iterations, a int) {
+			if iterations%checkpointEvery != 0 {
+				return
 			}
+			for i := range g.nodes {
+				weights[i] = g.nodes[i].weight[a]
+			}
+			checkpoint(iterations, weights)
 		}
-		done <- true
 	}
-	i, flight := 0, 0
-	for i < len(nodes) && flight < NumCPU {
-		go normalize(&nodes[i])
-		flight++
-		i++
+
+	a := g.iterate(&s)
+
+	for key, value := range g.index {
+		callback(key, g.nodes[value].weight[a])
+	}
+}
+
+// RankFiltered computes the PageRank of every node like Rank, but only calls
+// callback for the nodes set in emit that are part of the graph.
+func (g *Graph64) RankFiltered(α, ε float64, emit map[uint64]bool, callback func(id uint64, rank float64)) {
+	a := g.rank(α, ε)
+
+	for key, ok := range emit {
+		if !ok {
+			continue
+		}
+		if value, ok := g.index[key]; ok {
+			callback(key, g.nodes[value].weight[a])
+		}
+	}
+}
+
+// RankIndexed computes the PageRank of every node like Rank, but also passes the
+// internal index of every node to callback, for joins against data kept in
+// arrays indexed the same way.
+func (g *Graph64) RankIndexed(α, ε float64, callback func(index uint, id uint64, rank float64)) {
+	a := g.rank(α, ε)
+
+	for key, value := range g.index {
+		callback(value, key, g.nodes[value].weight[a])
+	}
+}
+
+// SetTimestamp sets the timestamp of a node, used by RankRecencyBiased.
+func (g *Graph64) SetTimestamp(id uint64, t int64) {
+	if g.timestamps == nil {
+		g.timestamps = make(map[uint]int64)
 	}
-	for i < len(nodes) {
-		<-done
-		flight--
-		go normalize(&nodes[i])
-		flight++
-		i++
+	g.timestamps[g.add(id)] = t
+}
+
+// RankRecencyBiased computes the PageRank of every node like Rank, but teleports
+// preferentially to recent nodes: the teleport probability of a node halves every
+// halfLife, in the unit of the timestamps, that it is older than the newest node.
+// Nodes without a timestamp are treated as having timestamp 0. A halfLife that
+// isn't positive gives uniform teleportation.
+func (g *Graph64) RankRecencyBiased(α, ε, halfLife float64, callback func(id uint64, rank float64)) {
+	s := settings64{α: α, ε: ε}
+	if halfLife > 0 && len(g.nodes) > 0 {
+		newest := int64(math.MinInt64)
+		for i := range g.nodes {
+			if t := g.timestamps[uint(i)]; t > newest {
+				newest = t
+			}
+		}
+		teleport, sum := make([]float64, len(g.nodes)), float64(0)
+		for i := range teleport {
+			age := float64(newest - g.timestamps[uint(i)])
+			teleport[i] = math.Exp2(-age / halfLife)
+			sum += teleport[i]
+		}
+		for i := range teleport {
+			teleport[i] /= sum
+		}
+		s.teleport = teleport
 	}
-	for j := 0; j < flight; j++ {
-		<-done
+
+	a := g.iterate(&s)
+
+	for key, value := range g.index {
+		callback(key, g.nodes[value].weight[a])
 	}
+}
 
-	if g.Verbose {
-		fmt.Println("initialize...")
+// RankVector computes the PageRank of every node like Rank, but returns the ranks
+// in a slice indexed by internal index instead of calling a callback.
+func (g *Graph64) RankVector(α, ε float64) []float64 {
+	a := g.rank(α, ε)
+
+	ranks := make([]float64, len(g.nodes))
+	for i := range g.nodes {
+		ranks[i] = g.nodes[i].weight[a]
 	}
-	leak := float64(0)
+	return ranks
+}
 
-	a, b := 0, 1
-	for source := range nodes {
-		nodes[source].weight[a] = inverse
+// Result is the rank of a node.
+type Result struct {
+	ID   uint64
+	Rank float64
+}
+
+// RankBatched computes the PageRank of every node like Rank, but passes the
+// results to callback in batches of batchSize, by internal index, which amortizes
+// the call overhead for bulk writes. The batch is reused between calls, so it
+// must be copied to be kept. A batchSize that isn't positive passes all the
+// results in a single batch.
+func (g *Graph64) RankBatched(α, ε float64, batchSize int, callback func(batch []Result)) {
+	a := g.iterate(&settings64{α: α, ε: ε})
+
+	if batchSize <= 0 || batchSize > len(g.nodes) {
+		batchSize = len(g.nodes)
+	}
+	ids := g.ids()
+	batch := make([]Result, 0, batchSize)
+	for i, id := range ids {
+		batch = append(batch, Result{ID: id, Rank: g.nodes[i].weight[a]})
+		if len(batch) == batchSize {
+			callback(batch)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		callback(batch)
+	}
+}
+
+// RankSchedule computes the PageRank of every node like Rank, but with a damping
+// factor that varies across iterations: alphas[i] is used at iteration i, and the
+// last one thereafter, for example to start low and converge quickly toward the
+// ranks of a higher one. Only the last damping factor determines the ranks
+// converged to. Nothing is ranked if alphas is empty.
+func (g *Graph64) RankSchedule(alphas []float64, ε float64, callback func(id uint64, rank float64)) {
+	if len(alphas) == 0 {
+		return
+	}
+	a := g.iterate(&settings64{α: alphas[0], ε: ε, schedule: alphas})
+
+	for key, value := range g.index {
+		callback(key, g.nodes[value].weight[a])
+	}
+}
+
+// RankQuery computes the PageRank of every node like Rank, but only returns the
+// ranks of the given nodes, which avoids materializing the ranks of the whole
+// graph when few are needed. Unknown ids are left out.
+func (g *Graph64) RankQuery(ids []uint64, α, ε float64) map[uint64]float64 {
+	a := g.iterate(&settings64{α: α, ε: ε})
 
-		if nodes[source].outbound == 0 {
-			leak += inverse
+	ranks := make(map[uint64]float64, len(ids))
+	for _, id := range ids {
+		if i, ok := g.index[id]; ok {
+			ranks[id] = g.nodes[i].weight[a]
 		}
 	}
+	return ranks
+}
 
-	update := func(adjustment float64, node *Node64) {
-		node.RLock()
-		aa := α * node.weight[a]
-		node.RUnlock()
-		for target, weight := range node.edges {
-			nodes[target].Lock()
-			nodes[target].weight[b] += aa * weight
-			nodes[target].Unlock()
-		}
-		node.Lock()
-		bb := node.weight[b]
-		node.weight[b] = bb + adjustment
-		node.Unlock()
-		done <- true
+// RankCapped computes the PageRank of every node like Rank, but caps the rank of
+// every node at maxShare of the total rank after every iteration, and gives the
+// excess to the other nodes as extra teleport mass, so that a single hub can't
+// drown out every other node. This is a heuristic departure from PageRank: the
+// result is not the stationary distribution of a random surfer.
+func (g *Graph64) RankCapped(maxShare float64, α, ε float64, callback func(id uint64, rank float64)) {
+	a := g.iterate(&settings64{α: α, ε: ε, maxShare: maxShare})
+
+	for key, value := range g.index {
+		callback(key, g.nodes[value].weight[a])
 	}
-	for Δ > ε {
-		if g.Verbose {
-			fmt.Println("updating...")
+}
+
+// RankNormalizedByDegree computes the PageRank of every node like Rank, and
+// reports its rank divided by its degree, the number of edges rank flows along
+// into the node if byIn is set or out of it otherwise, as a per link score.
+// Nodes without such edges have a score of 0.
+func (g *Graph64) RankNormalizedByDegree(α, ε float64, byIn bool, callback func(id uint64, score float64)) {
+	a := g.rank(α, ε)
+
+	var inbound [][]link64
+	if byIn {
+		inbound = g.inbound()
+	}
+	for key, value := range g.index {
+		node := &g.nodes[value]
+		degree := len(node.edges)
+		if byIn {
+			degree = len(inbound[value])
+		} else if node.dangling() {
+			degree = 0
 		}
-		adjustment := (1-α)*inverse + α*leak*inverse
-		i, flight := 0, 0
-		for i < len(nodes) && flight < NumCPU {
-			go update(adjustment, &nodes[i])
-			flight++
-			i++
+		score := float64(0)
+		if degree > 0 {
+			score = node.weight[a] / float64(degree)
 		}
-		for i < len(nodes) {
-			<-done
-			flight--
-			go update(adjustment, &nodes[i])
-			flight++
-			i++
+		callback(key, score)
+	}
+}
+
+// suggestSteps is the number of bisection steps of SuggestAlpha.
+const suggestSteps = 20
+
+// SuggestAlpha returns the largest damping factor α for which a ranking like Rank
+// converges to ε within maxIter iterations, found by bisection over [0, 1) with a
+// ranking at every candidate. Higher damping factors follow the links further
+// but converge more slowly. It returns 0 if even α = 0 doesn't converge, which
+// only happens for a maxIter below 1.
+func (g *Graph64) SuggestAlpha(ε float64, maxIter int) float64 {
+	saved := g.MaxIterations
+	defer func() {
+		g.MaxIterations = saved
+	}()
+	g.MaxIterations = maxIter
+	if maxIter < 1 {
+		return 0
+	}
+
+	converges := func(α float64) bool {
+		g.iterate(&settings64{α: α, ε: ε})
+		return g.LastRun.Converged
+	}
+	low, high := float64(0), float64(1)
+	for step := 0; step < suggestSteps; step++ {
+		α := (low + high) / 2
+		if converges(α) {
+			low = α
+		} else {
+			high = α
 		}
-		for j := 0; j < flight; j++ {
-			<-done
+	}
+	return low
+}
+
+// TopNContext computes the PageRank of every node like Rank and returns the n
+// highest ranked nodes, sorted by decreasing rank and then by id, but stops as
+// soon as ctx is done. It then returns the best effort top n from the ranks of
+// the last iteration along with ctx.Err(); LastRun.Converged tells whether the
+// ranks converged. A n that isn't positive returns every node.
+func (g *Graph64) TopNContext(ctx context.Context, α, ε float64, n int) ([]Result, error) {
+	a := g.iterate(&settings64{α: α, ε: ε, ctx: ctx})
+
+	results := make([]Result, 0, len(g.nodes))
+	for key, value := range g.index {
+		results = append(results, Result{ID: key, Rank: g.nodes[value].weight[a]})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
 		}
+		return results[i].ID < results[j].ID
+	})
+	if n > 0 && n < len(results) {
+		results = results[:n]
+	}
+	if g.LastRun.Reason == ReasonCancelled {
+		return results, ctx.Err()
+	}
+	return results, nil
+}
 
-		if g.Verbose {
-			fmt.Println("computing delta...")
+// RankAgainst computes the PageRank of every node like Rank, and reports each rank
+// with its delta from the baseline rank of the node. Nodes missing from baseline
+// have a baseline rank of 0, and ids of baseline that are not in the graph are
+// reported with a rank of 0 and a negative delta.
+func (g *Graph64) RankAgainst(baseline map[uint64]float64, α, ε float64, callback func(id uint64, rank, delta float64)) {
+	a := g.rank(α, ε)
+
+	for key, value := range g.index {
+		rank := g.nodes[value].weight[a]
+		callback(key, rank, rank-baseline[key])
+	}
+	for key, rank := range baseline {
+		if _, ok := g.index[key]; !ok {
+			callback(key, 0, -rank)
 		}
-		Δ, leak = 0, 0
-		for source := range nodes {
-			node := &nodes[source]
-			aa, bb := node.weight[a], node.weight[b]
-			if difference := aa - bb; difference < 0 {
-				Δ -= difference
-			} else {
-				Δ += difference
-			}
+	}
+}
+
+// RankWarmVector computes the PageRank of every node like Rank, but starts the
+// iteration from initial, indexed by internal index, for example the result of a
+// previous RankVector. initial is ignored unless it holds one rank per node.
+// The rank held by the dangling nodes in initial can optionally be given as leak,
+// when the dangling nodes are known not to have changed, to skip computing it.
+func (g *Graph64) RankWarmVector(α, ε float64, initial []float64, callback func(id uint64, rank float64), leak ...float64) {
+	s := settings64{α: α, ε: ε}
+	if len(initial) == len(g.nodes) {
+		s.initial = initial
+		if len(leak) == 1 {
+			s.leak = &leak[0]
+		}
+	}
+
+	a := g.iterate(&s)
+
+	for key, value := range g.index {
+		callback(key, g.nodes[value].weight[a])
+	}
+}
+
+// RankReachable computes the personalized PageRank of the nodes within maxHops
+// outbound hops of seed, teleporting to seed only. The ranking runs on the
+// subgraph induced by these nodes, which bounds memory and runtime for localized
+// queries: edges leaving the subgraph are dropped, so the rank of the nodes at its
+// border flows along their remaining edges only. The subgraph keeps the ranking
+// settings of the graph, and the damping factors, restart probabilities and pins
+// of its nodes; the teleport distribution is replaced by the seed.
+func (g *Graph64) RankReachable(seed uint64, maxHops int, α, ε float64, callback func(id uint64, rank float64)) {
+	s, ok := g.index[seed]
+	if !ok {
+		return
+	}
+	hops := g.hops(s, maxHops)
 
-			if node.outbound == 0 {
-				leak += bb
+	ids := g.ids()
+	subgraph := NewGraph64()
+	g.copySettings(subgraph)
+	subgraph.add(seed)
+	for source, hop := range hops {
+		if hop < 0 {
+			continue
+		}
+		id := ids[source]
+		subgraph.add(id)
+		if alpha, ok := g.damping[uint(source)]; ok {
+			subgraph.SetDamping(id, alpha)
+		}
+		if p, ok := g.restart[uint(source)]; ok {
+			subgraph.SetRestart(id, p)
+		}
+		if rank, ok := g.pins[uint(source)]; ok {
+			subgraph.Pin(id, rank)
+		}
+		node := &g.nodes[source]
+		for target, weight := range node.edges {
+			if hops[target] >= 0 {
+				subgraph.Link(ids[source], ids[target], g.raw(node, weight))
 			}
-			nodes[source].weight[a] = 0
 		}
+	}
 
-		a, b = b, a
+	teleport := make([]float64, len(subgraph.nodes))
+	teleport[0] = 1
+	a := subgraph.iterate(&settings64{α: α, ε: ε, teleport: teleport})
+	g.LastRun = subgraph.LastRun
 
-		if g.Verbose {
-			fmt.Println(Δ, ε)
+	for key, value := range subgraph.index {
+		callback(key, subgraph.nodes[value].weight[a])
+	}
+}
+
+// copySettings copies the ranking settings of the graph, but not those of its
+// nodes, to a graph built from it.
+func (g *Graph64) copySettings(view *Graph64) {
+	view.Verbose = g.Verbose
+	view.MaxEdgeContribution = g.MaxEdgeContribution
+	view.OnStable = g.OnStable
+	view.DanglingMode = g.DanglingMode
+	view.RestartMode = g.RestartMode
+	view.Acceleration = g.Acceleration
+	view.MaxIterations = g.MaxIterations
+	view.StallWindow = g.StallWindow
+	view.Progress = g.Progress
+	view.LeakProgress = g.LeakProgress
+	view.Deterministic = g.Deterministic
+	view.Transposed = g.Transposed
+	view.MinRank = g.MinRank
+}
+
+// RankFromNode computes the personalized PageRank of every node with respect to
+// a single seed, a random walk with restart: teleportation and the rank leaked by
+// dangling nodes always go back to the seed. Nothing is ranked if the seed isn't
+// in the graph.
+func (g *Graph64) RankFromNode(seed uint64, α, ε float64, callback func(id uint64, rank float64)) {
+	s, ok := g.index[seed]
+	if !ok {
+		return
+	}
+	teleport := make([]float64, len(g.nodes))
+	teleport[s] = 1
+	a := g.iterate(&settings64{α: α, ε: ε, teleport: teleport})
+
+	for key, value := range g.index {
+		callback(key, g.nodes[value].weight[a])
+	}
+}
+
+// RankDecomposed computes the PageRank of every node like Rank, but splits the
+// rank of every node into the rank propagated along its inbound edges and the
+// rank received by teleportation, which includes the rank leaked by dangling
+// nodes. The two sum to the rank of the node.
+func (g *Graph64) RankDecomposed(α, ε float64, callback func(id uint64, fromLinks, fromTeleport float64)) {
+	teleported := make([]float64, len(g.nodes))
+	a := g.iterate(&settings64{α: α, ε: ε, teleported: teleported})
+
+	for key, value := range g.index {
+		rank := g.nodes[value].weight[a]
+		callback(key, rank-teleported[value], teleported[value])
+	}
+}
+
+// RankUntilOrderStable computes the PageRank of every node like Rank, but stops
+// once the order of the k highest ranked nodes, ties broken by internal index,
+// has not changed for patience iterations, which usually happens long before
+// the values converge. Only the order of these nodes is meaningful; MaxIterations
+// bounds the ranking if the order keeps changing.
+func (g *Graph64) RankUntilOrderStable(k, patience int, α float64, callback func(id uint64, rank float64)) {
+	if k > len(g.nodes) {
+		k = len(g.nodes)
+	}
+	order := make([]int, len(g.nodes))
+	top, unchanged := make([]int, k), 0
+	s := settings64{α: α}
+	s.settled = func(iterations, a int) bool {
+		for i := range order {
+			order[i] = i
+		}
+		nodes := g.nodes
+		sort.Slice(order, func(i, j int) bool {
+			x, y := nodes[order[i]].weight[a], nodes[order[j]].weight[a]
+			if x != y {
+				return x > y
+			}
+			return order[i] < order[j]
+		})
+		same := iterations > 1
+		for i := range top {
+			if top[i] != order[i] {
+				top[i], same = order[i], false
+			}
 		}
+		if same {
+			unchanged++
+		} else {
+			unchanged = 0
+		}
+		return unchanged >= patience
 	}
+	a := g.iterate(&s)
 
 	for key, value := range g.index {
-		callback(key, nodes[value].weight[a])
+		callback(key, g.nodes[value].weight[a])
 	}
 }
 
-// Reset clears all the current graph data.
-func (g *Graph64) Reset(size ...int) {
-	capacity := 8
-	if len(size) == 1 {
-		capacity = size[0]
+// RankUntilTopKStable is RankUntilOrderStable with the arguments in the order of
+// the other rankings: it stops once the identity and order of the k highest
+// ranked nodes has not changed for stableIters consecutive iterations.
+func (g *Graph64) RankUntilTopKStable(α float64, k, stableIters int, callback func(id uint64, rank float64)) {
+	g.RankUntilOrderStable(k, stableIters, α, callback)
+}
+
+// RankMassByHop computes the personalized PageRank of the seed and returns, for
+// every hop distance h up to maxHop, the fraction of the rank mass held by the
+// nodes at most h outbound hops away from the seed. It returns nil if the seed
+// isn't in the graph.
+func (g *Graph64) RankMassByHop(seed uint64, α, ε float64, maxHop int) []float64 {
+	s, ok := g.index[seed]
+	if !ok || maxHop < 0 {
+		return nil
 	}
-	g.count = 0
-	g.index = make(map[uint64]uint, capacity)
-	g.nodes = make([]Node64, 0, capacity)
+	hops := g.hops(s, maxHop)
+
+	teleport := make([]float64, len(g.nodes))
+	teleport[s] = 1
+	a := g.iterate(&settings64{α: α, ε: ε, teleport: teleport})
+
+	mass, total := make([]float64, maxHop+1), 0.0
+	for i := range g.nodes {
+		weight := g.nodes[i].weight[a]
+		total += weight
+		if hop := hops[i]; hop >= 0 {
+			mass[hop] += weight
+		}
+	}
+	for hop := range mass {
+		if hop > 0 {
+			mass[hop] += mass[hop-1]
+		}
+	}
+	if total > 0 {
+		for hop := range mass {
+			mass[hop] /= total
+		}
+	}
+	return mass
+}
+
+// InboundContributions computes the converged ranks and returns, for every node
+// linking to id, the rank flowing along that edge: α * rank(source) * P(source→id).
+func (g *Graph64) InboundContributions(id uint64, α, ε float64) map[uint64]float64 {
+	contributions := make(map[uint64]float64)
+	t, ok := g.index[id]
+	if !ok {
+		return contributions
+	}
+
+	a := g.rank(α, ε)
+
+	ids := g.ids()
+	for _, link := range g.inbound()[t] {
+		contributions[ids[link.node]] = α * g.nodes[link.node].weight[a] * link.weight
+	}
+	return contributions
+}
+
+// OutInfluence computes the converged ranks and returns the topK targets of the
+// edges of source that receive the most rank from it, α * rank(source) *
+// P(source→target), sorted by decreasing rank and then by id. A topK that isn't
+// positive returns every target. It complements InboundContributions.
+func (g *Graph64) OutInfluence(source uint64, α, ε float64, topK int) []Result {
+	s, ok := g.index[source]
+	if !ok {
+		return []Result{}
+	}
+	a := g.rank(α, ε)
+
+	node := &g.nodes[s]
+	ids := g.ids()
+	results := make([]Result, 0, len(node.edges))
+	if !node.dangling() {
+		for target, weight := range node.edges {
+			results = append(results, Result{ID: ids[target], Rank: α * node.weight[a] * weight})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID < results[j].ID
+	})
+	if topK > 0 && topK < len(results) {
+		results = results[:topK]
+	}
+	return results
+}
+
+// Residual applies one step of the PageRank operator with damping factor α to
+// the given ranks and returns the L1 norm of the change, ||Mx - x||. Unlike the Δ
+// of the last iteration of a ranking, it measures how far the ranks actually are
+// from being stationary. Missing nodes have a rank of 0, and ids that are not in
+// the graph are ignored. The teleport distribution and the damping factors set on
+// the graph are used, and dangling nodes leak their rank like in Rank.
+func (g *Graph64) Residual(α float64, ranks map[uint64]float64) float64 {
+	g.normalize()
+	nodes := g.nodes
+	if len(nodes) == 0 {
+		return 0
+	}
+	teleport, alphas := g.teleport(nil), g.alphas(α)
+
+	x, y := make([]float64, len(nodes)), make([]float64, len(nodes))
+	for key, value := range g.index {
+		x[value] = ranks[key]
+	}
+	// mass is the rank teleported or leaked rather than propagated along edges.
+	mass := float64(0)
+	for source := range nodes {
+		node := &nodes[source]
+		mass += x[source]
+		if node.dangling() {
+			continue
+		}
+		aa := α * x[source]
+		if alphas != nil {
+			aa = alphas[source] * x[source]
+		}
+		mass -= aa
+		for target, weight := range node.edges {
+			y[target] += aa * weight
+		}
+	}
+
+	residual, inverse := float64(0), 1/float64(len(nodes))
+	for i := range y {
+		if teleport != nil {
+			y[i] += mass * teleport[i]
+		} else {
+			y[i] += mass * inverse
+		}
+		residual += math.Abs(y[i] - x[i])
+	}
+	return residual
+}
+
+// ids returns the external id of every node, indexed by internal index.
+func (g *Graph64) ids() []uint64 {
+	ids := make([]uint64, len(g.nodes))
+	for key, value := range g.index {
+		ids[value] = key
+	}
+	return ids
+}
+
+// normalize scales the edge weights of every node so that their sum amounts to 1.
+// The graph remembers that it is normalized, so this is only done once.
+func (g *Graph64) normalize() {
+	g.normalizeWith(NumCPU)
+}
+
+// normalizeChunk is the number of nodes a worker of normalizeWith takes at once.
+const normalizeChunk = 1024
+
+// normalizeWith normalizes the graph with the given number of goroutines.
+func (g *Graph64) normalizeWith(workers int) {
+	g.dequantize()
+	if g.normalized {
+		return
+	}
+	if g.Verbose {
+		fmt.Println("normalize...")
+	}
+	// Every worker normalizes ranges of nodes taken from a shared counter until
+	// none is left, instead of a goroutine being started per node.
+	nodes := g.nodes
+	if workers < 1 {
+		workers = 1
+	}
+	if chunks := (len(nodes) + normalizeChunk - 1) / normalizeChunk; workers > chunks {
+		workers = chunks
+	}
+	var next int64
+	var wait sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for {
+				end := int(atomic.AddInt64(&next, normalizeChunk))
+				start := end - normalizeChunk
+				if start >= len(nodes) {
+					return
+				}
+				if end > len(nodes) {
+					end = len(nodes)
+				}
+				for i := start; i < end; i++ {
+					node := &nodes[i]
+					if outbound := node.outbound; outbound > 0 && !node.dangling() {
+						for target := range node.edges {
+							node.edges[target] /= outbound
+						}
+					}
+				}
+			}
+		}()
+	}
+	wait.Wait()
+	g.normalized = true
+}
+
+// denormalize restores the raw edge weights of a normalized graph, so that more
+// edges can be added.
+func (g *Graph64) denormalize() {
+	g.dequantize()
+	g.transpose = nil
+	if !g.normalized {
+		return
+	}
+	for i := range g.nodes {
+		// The same nodes as normalizeWith divided are multiplied back.
+		node := &g.nodes[i]
+		if outbound := node.outbound; outbound > 0 && !node.dangling() {
+			for target := range node.edges {
+				node.edges[target] *= outbound
+			}
+		}
+	}
+	g.normalized = false
+}
+
+// SetTeleportable controls whether a node receives a share of the teleport
+// probability (1-α) and of the rank leaked by dangling nodes. Nodes are
+// teleportable by default; a node that is not only gains rank through its inbound
+// edges. The teleport distribution is renormalized over the teleportable nodes, so
+// the ranks still sum to 1. If no node is teleportable, teleportation is uniform.
+func (g *Graph64) SetTeleportable(id uint64, teleportable bool) {
+	i := g.add(id)
+	if teleportable {
+		delete(g.noTeleport, i)
+		return
+	}
+	if g.noTeleport == nil {
+		g.noTeleport = make(map[uint]bool)
+	}
+	g.noTeleport[i] = true
+}
+
+// TeleportBaseline returns the ranks of a pure teleport ranking, as with α = 0:
+// the share of rank every node receives from teleportation alone. It is a cheap
+// baseline to compare or subtract from the ranks computed by Rank.
+func (g *Graph64) TeleportBaseline() map[uint64]float64 {
+	baseline := make(map[uint64]float64, len(g.index))
+	teleport := g.teleport(nil)
+	inverse := 1 / float64(len(g.nodes))
+	for key, value := range g.index {
+		if teleport != nil {
+			baseline[key] = teleport[value]
+		} else {
+			baseline[key] = inverse
+		}
+	}
+	return baseline
+}
+
+// SetTeleport sets the teleport distribution used by the rankings that don't take
+// their own, such as Rank, from the teleport weight of every node; the weights
+// are normalized to sum to 1. Nodes missing from v, or not in the graph, are not
+// teleported to. The distribution is kept by id across Reset, so it can be reused
+// with changing graphs. SetTeleport(nil) restores the uniform teleport.
+func (g *Graph64) SetTeleport(v map[uint64]float64) {
+	g.preference, g.preferred = nil, nil
+	if v == nil {
+		return
+	}
+	g.preference = make(map[uint64]float64, len(v))
+	for id, weight := range v {
+		g.preference[id] = weight
+	}
+}
+
+// preferences returns the normalized teleport distribution set by SetTeleport,
+// indexed by internal index, or nil if there is none. It is cached until nodes are
+// added.
+func (g *Graph64) preferences() []float64 {
+	if g.preference == nil {
+		return nil
+	}
+	if len(g.preferred) == len(g.nodes) {
+		return g.preferred
+	}
+	preferred, sum := make([]float64, len(g.nodes)), float64(0)
+	for id, weight := range g.preference {
+		if i, ok := g.index[id]; ok && weight > 0 {
+			preferred[i] = weight
+			sum += weight
+		}
+	}
+	if sum == 0 {
+		return nil
+	}
+	for i := range preferred {
+		preferred[i] /= sum
+	}
+	g.preferred = preferred
+	return preferred
+}
+
+// teleport returns the teleport distribution to use for a ranking given the
+// requested one, excluding the nodes that are not teleportable. nil requests the
+// distribution set by SetTeleport, and nil means uniform.
+func (g *Graph64) teleport(requested []float64) []float64 {
+	if requested == nil {
+		requested = g.preferences()
+	}
+	if len(g.noTeleport) == 0 {
+		return requested
+	}
+	teleport, sum := make([]float64, len(g.nodes)), float64(0)
+	for i := range teleport {
+		if g.noTeleport[uint(i)] {
+			continue
+		}
+		teleport[i] = 1
+		if requested != nil {
+			teleport[i] = requested[i]
+		}
+		sum += teleport[i]
+	}
+	if sum == 0 {
+		return requested
+	}
+	for i := range teleport {
+		teleport[i] /= sum
+	}
+	return teleport
+}
+
+// SetDamping overrides the damping factor α of a single node: the node propagates
+// α of its rank along its outbound edges and teleports the rest. Trusted nodes can
+// be given a higher α than suspicious ones. Dangling nodes teleport all of their
+// rank regardless of their damping factor, so the ranks still sum to 1.
+func (g *Graph64) SetDamping(id uint64, alpha float64) {
+	if g.damping == nil {
+		g.damping = make(map[uint]float64)
+	}
+	g.damping[g.add(id)] = alpha
+}
+
+// SetRestart gives a node a restart probability p. With the default
+// RestartScaled mode, the random surfer restarts with probability p at the node
+// before following the damping factor α, or the one set by SetDamping, so the
+// node propagates α(1-p) of its rank along its outbound edges and teleports the
+// rest. With RestartAbsolute, the node propagates 1-p of its rank whatever α is.
+// Either way, the teleported rank and the rank leaked by dangling nodes, which
+// leak all of their rank regardless of their restart probability, are spread
+// along the teleport distribution, so the ranks still sum to 1.
+func (g *Graph64) SetRestart(id uint64, p float64) {
+	if g.restart == nil {
+		g.restart = make(map[uint]float64)
+	}
+	g.restart[g.add(id)] = p
+}
+
+// alpha returns the damping factor of node i given the global α.
+func (g *Graph64) alpha(i uint, α float64) float64 {
+	if p, ok := g.restart[i]; ok && g.RestartMode == RestartAbsolute {
+		return 1 - p
+	}
+	if alpha, ok := g.damping[i]; ok {
+		α = alpha
+	}
+	return α * (1 - g.restart[i])
+}
+
+// alphas returns the damping factor of every node, or nil if they all use α.
+func (g *Graph64) alphas(α float64) []float64 {
+	if len(g.damping) == 0 && len(g.restart) == 0 {
+		return nil
+	}
+	alphas := make([]float64, len(g.nodes))
+	for i := range alphas {
+		alphas[i] = g.alpha(uint(i), α)
+	}
+	return alphas
+}
+
+// RecordHistory streams the rank of every node after every iteration of the
+// following rankings to w, as iteration,id,rank rows. The rows are buffered and
+// flushed at the end of every ranking; write errors end the recording silently.
+// Passing nil stops recording.
+func (g *Graph64) RecordHistory(w io.Writer) {
+	if w == nil {
+		g.history = nil
+		return
+	}
+	g.history = bufio.NewWriter(w)
+}
+
+// record writes the history rows of an iteration.
+func (g *Graph64) record(iteration, a int, ids []uint64) {
+	row := make([]byte, 0, 64)
+	for i := range g.nodes {
+		row = strconv.AppendInt(row[:0], int64(iteration), 10)
+		row = append(row, ',')
+		row = strconv.AppendUint(row, ids[i], 10)
+		row = append(row, ',')
+		row = strconv.AppendFloat(row, g.nodes[i].weight[a], 'g', -1, 64)
+		row = append(row, '\n')
+		if _, err := g.history.Write(row); err != nil {
+			return
+		}
+	}
+}
+
+// link64 is an edge from or to a node, given by internal index.
+type link64 struct {
+	node   uint
+	weight float64
+}
+
+// inbound returns the inbound edges of every node with their normalized weights,
+// sorted by source. They are cached until the graph is modified.
+func (g *Graph64) inbound() [][]link64 {
+	g.normalize()
+	if g.transpose != nil {
+		return g.transpose
+	}
+
+	inbound := make([][]link64, len(g.nodes))
+	for source := range g.nodes {
+		if g.nodes[source].dangling() {
+			continue
+		}
+		for target, weight := range g.nodes[source].edges {
+			inbound[target] = append(inbound[target], link64{node: uint(source), weight: weight})
+		}
+	}
+	g.transpose = inbound
+	return inbound
+}
+
+// InDegree returns the number of edges pointing at a node, from the cached
+// inbound edges.
+func (g *Graph64) InDegree(id uint64) int {
+	t, ok := g.index[id]
+	if !ok {
+		return 0
+	}
+	return len(g.inbound()[t])
+}
+
+// InvalidateCache drops the inbound edges cached by Transposed and Deterministic
+// rankings and by the inbound queries such as InDegree, TopInbound and
+// InboundContributions. The cache is dropped automatically when the graph is
+// modified; this only releases its memory.
+func (g *Graph64) InvalidateCache() {
+	g.transpose = nil
+}
+
+// settings64 holds the parameters of a single ranking.
+type settings64 struct {
+	α, ε float64
+	// fixed runs exactly iterations update steps; Δ is then never computed.
+	fixed      bool
+	iterations int
+	// initial seeds the ranks, indexed by internal index, instead of the uniform
+	// distribution.
+	initial []float64
+	// leak, when set, is the rank of the dangling nodes in initial, which is then
+	// not computed unless the damping varies per node or DanglingSink is used.
+	leak *float64
+	// teleport is the distribution of the teleport and leaked mass, indexed by
+	// internal index; nil means uniform.
+	teleport []float64
+	// teleported, when set, receives the teleport and leaked rank every node
+	// received in the last iteration, indexed by internal index.
+	teleported []float64
+	// workers is the number of goroutines updating nodes, NumCPU if not positive.
+	workers int
+	// quantized ranks a quantized graph from its levels.
+	quantized bool
+	// ctx, when set, stops the iteration once it is done.
+	ctx context.Context
+	// after is called at the end of every iteration with the iteration count and
+	// the node weight holding the current ranks.
+	after func(iterations, a int)
+	// schedule, when set, is the damping factor of every iteration, the last one
+	// being repeated; it overrides α.
+	schedule []float64
+	// settled, when set, is called at the end of every iteration like after, and
+	// stops the ranking with ReasonOrderStable once it returns true.
+	settled func(iterations, a int) bool
+	// maxShare, when positive, caps the share of the total rank of every node
+	// after every iteration.
+	maxShare float64
+	// constrained applies the constraints set by SetConstraint after every
+	// iteration.
+	constrained bool
+}
+
+// partialBudget bounds the number of float64 in the partial vectors the workers
+// push the ranks into, 1<<27 being 1 GiB. Graphs too large for a partial vector
+// per worker are pushed by fewer workers.
+const partialBudget = 1 << 27
+
+// rank normalizes the graph and iterates until it converges.
+// It returns which of the two node weights holds the result.
+func (g *Graph64) rank(α, ε float64) int {
+	return g.iterate(&settings64{α: α, ε: ε})
+}
+
+// iterate normalizes the graph and runs the power iteration described by s.
+// It returns which of the two node weights holds the result.
+func (g *Graph64) iterate(s *settings64) int {
+	workers := s.workers
+	if workers <= 0 {
+		workers = NumCPU
+	}
+	if !s.quantized {
+		g.normalizeWith(workers)
+	}
+
+	α, ε := s.α, s.ε
+	fixed := s.fixed
+	Δ := float64(1.0)
+	nodes := g.nodes
+	inverse := 1 / float64(len(nodes))
+	teleport := g.teleport(s.teleport)
+	alphas := g.alphas(α)
+
+	if g.Verbose {
+		fmt.Println("initialize...")
+	}
+	// leak is the rank of the dangling nodes, retained the rank propagated along
+	// edges and drained the rank propagated by dangling nodes when the damping
+	// varies per node, sink the rank of the virtual sink node.
+	leak, retained, drained, total := float64(0), float64(0), float64(0), float64(0)
+	sinked, sink := g.DanglingMode == DanglingSink, float64(0)
+	account := func(source int, weight float64) {
+		if nodes[source].dangling() {
+			leak += weight
+			if alphas != nil {
+				drained += alphas[source] * weight
+			}
+		} else if alphas != nil {
+			retained += alphas[source] * weight
+		}
+		total += weight
+	}
+
+	var ids []uint64
+	var stable []bool
+	if g.OnStable != nil && !fixed {
+		stable = make([]bool, len(nodes))
+	}
+	if stable != nil || g.history != nil {
+		ids = g.ids()
+	}
+
+	// The leak of the initial ranks can be given when only it is needed.
+	known := s.leak != nil && alphas == nil && !sinked
+	a, b, iterations := 0, 1, 0
+	// cumulative is the rank leaked over all the iterations.
+	cumulative := float64(0)
+	for source := range nodes {
+		nodes[source].weight[a] = inverse
+		if s.initial != nil {
+			nodes[source].weight[a] = s.initial[source]
+		}
+		nodes[source].weight[b] = 0
+		if rank, ok := g.pins[uint(source)]; ok {
+			nodes[source].weight[a] = rank
+		}
+
+		if !known {
+			account(source, nodes[source].weight[a])
+		}
+	}
+	if known {
+		leak = *s.leak
+	}
+
+	// The nodes are split into one contiguous chunk per worker.
+	chunks := workers
+	if chunks > len(nodes) {
+		chunks = len(nodes)
+	}
+	size := 1
+	if chunks > 0 {
+		size = (len(nodes) + chunks - 1) / chunks
+	}
+	done := make(chan bool, 8)
+	// spread runs f over chunks of size nodes concurrently.
+	spread := func(size int, f func(chunk, start, end int)) {
+		flight := 0
+		for chunk := 0; chunk*size < len(nodes); chunk++ {
+			start, end := chunk*size, (chunk+1)*size
+			if end > len(nodes) {
+				end = len(nodes)
+			}
+			go f(chunk, start, end)
+			flight++
+		}
+		for ; flight > 0; flight-- {
+			<-done
+		}
+	}
+	parallel := func(f func(chunk, start, end int)) {
+		spread(size, f)
+	}
+
+	// clamped holds the rank clamped off edges by MaxEdgeContribution, per node
+	// summing the edges, so that it adds up in index order whatever the number
+	// of workers.
+	var clamped []float64
+	limit := g.MaxEdgeContribution
+	if limit > 0 {
+		clamped = make([]float64, len(nodes))
+	}
+	contribution := func(i int, c float64) float64 {
+		if limit > 0 && c > limit {
+			clamped[i] += c - limit
+			return limit
+		}
+		return c
+	}
+	// Every worker pushes the rank of its nodes into a private partial vector,
+	// so that no lock is taken per edge, and the partials are then summed.
+	var partial [][]float64
+	pushSize := size
+	push := func(chunk, start, end int) {
+		sums := partial[chunk]
+		for i := start; i < end; i++ {
+			node := &nodes[i]
+			if node.dangling() {
+				continue
+			}
+			aa := α * node.weight[a]
+			if alphas != nil {
+				aa = alphas[i] * node.weight[a]
+			}
+			for target, weight := range node.edges {
+				sums[target] += contribution(i, aa*weight)
+			}
+			if len(node.levels) > 0 && node.outbound > 0 {
+				aa *= node.scale / node.outbound
+				for target, level := range node.levels {
+					sums[target] += contribution(i, aa*float64(level))
+				}
+			}
+		}
+		done <- true
+	}
+	update := func(mass float64) func(chunk, start, end int) {
+		return func(chunk, start, end int) {
+			for i := start; i < end; i++ {
+				sum := mass * inverse
+				if teleport != nil {
+					sum = mass * teleport[i]
+				}
+				if s.teleported != nil {
+					s.teleported[i] = sum
+				}
+				for _, sums := range partial {
+					sum += sums[i]
+					sums[i] = 0
+				}
+				nodes[i].weight[b] = sum
+			}
+			done <- true
+		}
+	}
+	if (g.Deterministic || g.Transposed) && !s.quantized {
+		// Every node sums its inbound ranks in a fixed order instead.
+		inbound := g.inbound()
+		update = func(mass float64) func(chunk, start, end int) {
+			return func(chunk, start, end int) {
+				for i := start; i < end; i++ {
+					sum := mass * inverse
+					if teleport != nil {
+						sum = mass * teleport[i]
+					}
+					if s.teleported != nil {
+						s.teleported[i] = sum
+					}
+					for _, link := range inbound[i] {
+						aa := α * nodes[link.node].weight[a]
+						if alphas != nil {
+							aa = alphas[link.node] * nodes[link.node].weight[a]
+						}
+						sum += contribution(i, aa*link.weight)
+					}
+					nodes[i].weight[b] = sum
+				}
+				done <- true
+			}
+		}
+	} else {
+		// Every pushing worker needs a partial vector of all the nodes, so fewer
+		// workers push when they would take more than partialBudget.
+		pushers := chunks
+		if len(nodes) > 0 && pushers > partialBudget/len(nodes) {
+			pushers = partialBudget / len(nodes)
+			if pushers < 1 {
+				pushers = 1
+			}
+		}
+		if pushers > 0 {
+			pushSize = (len(nodes) + pushers - 1) / pushers
+		}
+		partial = make([][]float64, pushers)
+		for chunk := range partial {
+			partial[chunk] = make([]float64, len(nodes))
+		}
+	}
+	// older holds the ranks of the two iterations before the previous one.
+	var older [][2]float64
+	if g.Acceleration != AccelerationNone && !fixed {
+		older = make([][2]float64, len(nodes))
+	}
+	// lowest is the lowest Δ so far, reached stalled iterations ago.
+	lowest, stalled, settled := math.Inf(1), 0, false
+	reason := ""
+	for {
+		if fixed && iterations >= s.iterations {
+			reason = ReasonIterations
+			break
+		} else if !fixed && !(Δ > ε) {
+			reason = ReasonConverged
+			break
+		} else if !fixed && g.MaxIterations > 0 && iterations >= g.MaxIterations {
+			reason = ReasonMaxIterations
+			break
+		} else if settled {
+			reason = ReasonOrderStable
+			break
+		} else if !fixed && g.StallWindow > 0 && stalled >= g.StallWindow {
+			reason = ReasonStalled
+			break
+		} else if s.ctx != nil && s.ctx.Err() != nil {
+			reason = ReasonCancelled
+			break
+		}
+
+		if s.schedule != nil {
+			step := iterations
+			if step >= len(s.schedule) {
+				step = len(s.schedule) - 1
+			}
+			if α != s.schedule[step] {
+				α = s.schedule[step]
+				if alphas != nil {
+					// The rank retained along edges depends on the damping.
+					alphas = g.alphas(α)
+					leak, retained, drained, total = 0, 0, 0, 0
+					for source := range nodes {
+						account(source, nodes[source].weight[a])
+					}
+				}
+			}
+		}
+
+		if g.Verbose {
+			fmt.Println("updating...")
+		}
+		mass, next := (1-α)+α*leak, float64(0)
+		if alphas != nil {
+			mass = total - retained
+		}
+		if sinked {
+			// Dangling nodes propagate to the sink instead of leaking, and the
+			// sink propagates all of its rank uniformly.
+			kept, lost := α*(total-leak), α*leak
+			if alphas != nil {
+				kept, lost = retained, drained
+			}
+			mass, next = total-kept-lost+sink, lost
+		}
+		if partial != nil {
+			spread(pushSize, push)
+		}
+		parallel(update(mass))
+		if limit > 0 {
+			excess := float64(0)
+			for i := range clamped {
+				excess, clamped[i] = excess+clamped[i], 0
+			}
+			for i := range nodes {
+				share := excess * inverse
+				if teleport != nil {
+					share = excess * teleport[i]
+				}
+				nodes[i].weight[b] += share
+				if s.teleported != nil {
+					s.teleported[i] += share
+				}
+			}
+		}
+		if s.maxShare > 0 {
+			g.ceiling(b, s.maxShare, teleport)
+		}
+		if s.constrained {
+			g.constrain(b)
+		}
+
+		if g.Verbose && !fixed {
+			fmt.Println("computing delta...")
+		}
+		if older != nil && iterations > 1 && (iterations+1)%extrapolationPeriod == 0 {
+			g.extrapolate(older, a, b)
+		}
+		Δ, leak, retained, drained, total = 0, 0, 0, 0, 0
+		for source := range nodes {
+			node := &nodes[source]
+			if older != nil {
+				older[source] = [2]float64{older[source][1], node.weight[a]}
+			}
+			if rank, ok := g.pins[uint(source)]; ok {
+				node.weight[b] = rank
+			}
+			bb := node.weight[b]
+			if !fixed {
+				difference := node.weight[a] - bb
+				if difference < 0 {
+					difference = -difference
+				}
+				Δ += difference
+				if stable != nil && !stable[source] && difference < ε {
+					stable[source] = true
+					g.OnStable(ids[source], bb)
+				}
+			}
+
+			account(source, bb)
+			nodes[source].weight[a] = 0
+		}
+		if sinked {
+			Δ += math.Abs(sink - next)
+			sink = next
+		}
+		if Δ < lowest {
+			lowest, stalled = Δ, 0
+		} else {
+			stalled++
+		}
+
+		a, b = b, a
+		iterations++
+		if s.after != nil {
+			s.after(iterations, a)
+		}
+		if s.settled != nil {
+			settled = s.settled(iterations, a)
+		}
+		if g.Progress != nil {
+			g.Progress(iterations, Δ)
+		}
+		cumulative += leak
+		if g.LeakProgress != nil {
+			g.LeakProgress(iterations, leak)
+		}
+		if g.history != nil {
+			g.record(iterations, a, ids)
+		}
+
+		if g.Verbose && !fixed {
+			fmt.Println(Δ, ε)
+		}
+	}
+
+	leaked := leak
+	if sinked && total > 0 {
+		// The ranks of the real nodes are rescaled to sum to 1 without the sink.
+		for source := range nodes {
+			nodes[source].weight[a] /= total
+			if s.teleported != nil {
+				s.teleported[source] /= total
+			}
+		}
+		leaked /= total
+		cumulative /= total
+	}
+	if g.MinRank > 0 {
+		g.floor(a)
+	}
+	if g.history != nil {
+		g.history.Flush()
+	}
+	g.LastRun = Run64{
+		Iterations:  iterations,
+		Converged:   reason == ReasonConverged || reason == ReasonOrderStable,
+		Reason:      reason,
+		Leaked:      leaked,
+		TotalLeaked: cumulative,
+	}
+	if !fixed {
+		g.LastRun.Delta = Δ
+	}
+	g.ranked, g.slot, g.rankedAlpha = true, a, α
+	g.online = nil
+	return a
+}
+
+// LeakedMass returns the fraction of the rank held by dangling nodes at the end
+// of the last ranking. A high value means the graph is dominated by sinks. The
+// rank leaked over every iteration is in the TotalLeaked of LastRun.
+func (g *Graph64) LeakedMass() float64 {
+	return g.LastRun.Leaked
+}
+
+// ErrorBound returns a bound on the L1 distance between the ranks of the last
+// ranking and the true stationary distribution, αΔ/(1-α), from the damping factor
+// and the final Δ: the ranks x_k with ||x_k - x_(k-1)|| = Δ satisfy
+// ||x_k - x*|| <= α||x_(k-1) - x*|| <= α(||x_k - x*|| + Δ). The highest damping
+// factor of any node is used, as set by SetDamping and SetRestart. The bound is
+// +Inf if the graph has not been ranked, if α is 1, or if the last ranking didn't
+// compute Δ, like RankFixed. It doesn't hold for the rankings that depart from
+// the power iteration, such as with Acceleration, MinRank, MaxEdgeContribution or
+// RankCapped.
+func (g *Graph64) ErrorBound() float64 {
+	if !g.ranked || g.LastRun.Reason == ReasonIterations {
+		return math.Inf(1)
+	}
+	α := g.rankedAlpha
+	if alphas := g.alphas(g.rankedAlpha); alphas != nil {
+		α = 0
+		for _, alpha := range alphas {
+			if alpha > α {
+				α = alpha
+			}
+		}
+	}
+	if α >= 1 {
+		return math.Inf(1)
+	}
+	return α * g.LastRun.Delta / (1 - α)
+}
+
+// floor raises the ranks of slot a that are below MinRank to it and scales the
+// others so that the ranks keep their sum.
+func (g *Graph64) floor(a int) {
+	nodes := g.nodes
+	total := float64(0)
+	for i := range nodes {
+		total += nodes[i].weight[a]
+	}
+	floored := make([]bool, len(nodes))
+	count := 0
+	for {
+		rest := float64(0)
+		for i := range nodes {
+			if !floored[i] {
+				rest += nodes[i].weight[a]
+			}
+		}
+		budget := total - float64(count)*g.MinRank
+		if budget <= 0 || rest <= 0 {
+			for i := range nodes {
+				nodes[i].weight[a] = total / float64(len(nodes))
+			}
+			return
+		}
+		scale, changed := budget/rest, false
+		for i := range nodes {
+			if !floored[i] && nodes[i].weight[a]*scale < g.MinRank {
+				floored[i], changed = true, true
+				count++
+			}
+		}
+		if !changed {
+			for i := range nodes {
+				if floored[i] {
+					nodes[i].weight[a] = g.MinRank
+				} else {
+					nodes[i].weight[a] *= scale
+				}
+			}
+			return
+		}
+	}
+}
+
+// Constraint bounds the rank of a node in RankConstrained. A Ceiling that is not
+// positive doesn't bound the rank.
+type Constraint struct {
+	Floor, Ceiling float64
+}
+
+// SetConstraint bounds the rank of a node between c.Floor and c.Ceiling in
+// RankConstrained. A node can be given a prior importance with SetTeleport.
+func (g *Graph64) SetConstraint(id uint64, c Constraint) {
+	if g.constraints == nil {
+		g.constraints = make(map[uint]Constraint)
+	}
+	g.constraints[g.add(id)] = c
+}
+
+// RankConstrained computes the PageRank of every node like Rank, but clamps the
+// rank of the nodes with a constraint set by SetConstraint after every iteration,
+// and rescales the other ranks so that the ranks still sum to 1. The result is a
+// constrained approximation rather than the stationary distribution of a random
+// surfer. If the constraints can't be met together, such as floors summing to
+// more than 1, the ranks are clamped without being rescaled.
+func (g *Graph64) RankConstrained(α, ε float64, callback func(id uint64, rank float64)) {
+	a := g.iterate(&settings64{α: α, ε: ε, constrained: true})
+
+	for key, value := range g.index {
+		callback(key, g.nodes[value].weight[a])
+	}
+}
+
+// constrain clamps the ranks of slot b to the constraints of their nodes, and
+// scales the ranks of the other nodes so that the ranks keep their sum, clamping
+// again the nodes the scaling pushes out of their bounds.
+func (g *Graph64) constrain(b int) {
+	nodes := g.nodes
+	total := float64(0)
+	for i := range nodes {
+		total += nodes[i].weight[b]
+	}
+	clamped := make(map[uint]float64, len(g.constraints))
+	for {
+		fixed, rest := float64(0), float64(0)
+		for i := range nodes {
+			if rank, ok := clamped[uint(i)]; ok {
+				fixed += rank
+			} else {
+				rest += nodes[i].weight[b]
+			}
+		}
+		scale := float64(1)
+		if rest > 0 {
+			scale = (total - fixed) / rest
+		}
+		if scale < 0 {
+			scale = 0
+		}
+		changed := false
+		for i, c := range g.constraints {
+			if _, ok := clamped[i]; ok {
+				continue
+			}
+			rank := nodes[i].weight[b] * scale
+			if rank < c.Floor {
+				clamped[i], changed = c.Floor, true
+			} else if c.Ceiling > 0 && rank > c.Ceiling {
+				clamped[i], changed = c.Ceiling, true
+			}
+		}
+		if !changed {
+			for i := range nodes {
+				if rank, ok := clamped[uint(i)]; ok {
+					nodes[i].weight[b] = rank
+				} else {
+					nodes[i].weight[b] *= scale
+				}
+			}
+			return
+		}
+	}
+}
+
+// ceiling caps the rank of every node in slot b at share of the total rank, and
+// redistributes the excess to the nodes below the cap along the teleport
+// distribution, capping again the nodes it pushes over. If share is too small for
+// the nodes to reach the total, the ranks become uniform.
+func (g *Graph64) ceiling(b int, share float64, teleport []float64) {
+	nodes := g.nodes
+	total := float64(0)
+	for i := range nodes {
+		total += nodes[i].weight[b]
+	}
+	if share*float64(len(nodes)) <= 1 {
+		for i := range nodes {
+			nodes[i].weight[b] = total / float64(len(nodes))
+		}
+		return
+	}
+	limit := share * total
+	capped := make([]bool, len(nodes))
+	for {
+		excess, weights := float64(0), float64(0)
+		for i := range nodes {
+			if capped[i] {
+				continue
+			}
+			if rank := nodes[i].weight[b]; rank > limit {
+				excess += rank - limit
+				nodes[i].weight[b], capped[i] = limit, true
+			} else if teleport != nil {
+				weights += teleport[i]
+			} else {
+				weights++
+			}
+		}
+		if excess == 0 || weights == 0 {
+			return
+		}
+		for i := range nodes {
+			if capped[i] {
+				continue
+			}
+			if teleport != nil {
+				nodes[i].weight[b] += excess * teleport[i] / weights
+			} else {
+				nodes[i].weight[b] += excess / weights
+			}
+		}
+	}
+}
+
+// extrapolate replaces the ranks of slot b with their quadratic extrapolation
+// from the ranks of the three previous iterations, in older and slot a. The
+// coefficients are the least squares solution of Y γ = -y3 where the columns of
+// Y are the differences y1 and y2 of the two middle iterates with the oldest one,
+// and y3 that of the newest. The extrapolated ranks are clipped to be
+// nonnegative and rescaled to keep their sum.
+func (g *Graph64) extrapolate(older [][2]float64, a, b int) {
+	nodes := g.nodes
+	// The normal equations of the least squares problem.
+	var s11, s12, s22, r1, r2 float64
+	for i := range nodes {
+		x0, x1, x2, x3 := older[i][0], older[i][1], nodes[i].weight[a], nodes[i].weight[b]
+		y1, y2, y3 := x1-x0, x2-x0, x3-x0
+		s11 += y1 * y1
+		s12 += y1 * y2
+		s22 += y2 * y2
+		r1 -= y1 * y3
+		r2 -= y2 * y3
+	}
+	det := s11*s22 - s12*s12
+	if det == 0 {
+		return
+	}
+	γ1, γ2 := (r1*s22-r2*s12)/det, (s11*r2-s12*r1)/det
+	β0, β1, β2 := γ1+γ2+1, γ2+1, 1.0
+	before, after := float64(0), float64(0)
+	for i := range nodes {
+		x1, x2, x3 := older[i][1], nodes[i].weight[a], nodes[i].weight[b]
+		before += x3
+		x := β0*x1 + β1*x2 + β2*x3
+		if x < 0 {
+			x = 0
+		}
+		nodes[i].weight[b] = x
+		after += x
+	}
+	if after > 0 {
+		for i := range nodes {
+			nodes[i].weight[b] *= before / after
+		}
+	}
+}
+
+// Reset clears all the current graph data.
+func (g *Graph64) Reset(size ...int) {
+	capacity := 8
+	if len(size) == 1 {
+		capacity = size[0]
+	}
+	g.index = make(map[uint64]uint, capacity)
+	g.nodes = make([]Node64, 0, capacity)
+	g.clear()
+}
+
+// ResetKeepCapacity clears all the current graph data like Reset, but keeps the
+// memory already allocated for nodes, which avoids repeated large allocations
+// when ranking many graphs of similar size.
+func (g *Graph64) ResetKeepCapacity() {
+	for key := range g.index {
+		delete(g.index, key)
+	}
+	for i := range g.nodes {
+		g.nodes[i] = Node64{}
+	}
+	g.nodes = g.nodes[:0]
+	g.clear()
+}
+
+// clear resets everything but the node storage.
+func (g *Graph64) clear() {
+	g.count = 0
+	g.Rejected = 0
+	g.normalized = false
+	g.transpose = nil
+	g.quantized = false
+	g.pins = nil
+	g.noTeleport = nil
+	g.preferred = nil
+	g.ranked = false
+	g.online = nil
+	g.damping = nil
+	g.timestamps = nil
+	g.typed = nil
+	g.layers = nil
+	g.constraints = nil
+	g.restart = nil
+	g.LastRun = Run64{}
 }
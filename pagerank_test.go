@@ -1,7 +1,13 @@
 package pagerank
 
 import (
+	"bytes"
+	"context"
+	"math"
+	"math/rand"
 	"reflect"
+	"sort"
+	"sync"
 	"testing"
 )
 
@@ -28,6 +34,34 @@ func TestEmpty64(t *testing.T) {
 	}
 }
 
+func TestSingleNode64(t *testing.T) {
+	expected := map[uint64]float64{42: 1.0}
+
+	graph := NewGraph64()
+	graph.AddNode(42)
+
+	actual := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(actual, expected) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	graph = NewGraph64()
+	graph.Link(42, 42, 1.0)
+
+	actual = map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(actual, expected) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
 func TestSimple64(t *testing.T) {
 	graph := NewGraph64()
 
@@ -45,7 +79,1408 @@ func TestSimple64(t *testing.T) {
 		4: 0.16142193337298952,
 	}
 
-	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestWeighted64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.34983779905464363,
+		2: 0.1688733284604475,
+		3: 0.3295121849483849,
+		4: 0.15177668753652385,
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestDuplicates64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	graph.Link(1, 2, 6.0)
+	graph.Link(1, 3, 7.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.3312334209098247,
+		2: 0.19655848316544225,
+		3: 0.3033555769882879,
+		4: 0.168852518936445,
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestDuplicatesAfterReset64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	graph.Reset()
+
+	graph.Link(1, 2, 6.0)
+	graph.Link(1, 3, 7.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.25974019022001016,
+		2: 0.3616383883769191,
+		3: 0.3786214214030706,
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestPin64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 1.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(2, 4, 1.0)
+	graph.Link(3, 1, 1.0)
+
+	graph.Pin(4, 0.5)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.655099755553318,
+		2: 0.4221672507790805,
+		3: 0.6015882502193952,
+		4: 0.5,
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestInboundContributions64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	expected := map[uint64]float64{
+		1: 0.19824141946429802,
+		2: 0.06151814108202016,
+	}
+
+	actual := graph.InboundContributions(3, 0.85, 0.000001)
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestOutInfluence64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	actual := graph.OutInfluence(2, 0.85, 0.000001, 1)
+	if len(actual) != 1 || actual[0].ID != 4 || math.Abs(actual[0].Rank-0.06151814108202016*4/3) > 0.000001 {
+		t.Error("Expected node 4 to receive the most rank but got", actual)
+	}
+	if contributions := graph.InboundContributions(3, 0.85, 0.000001); int(1000*contributions[2]) != int(1000*graph.OutInfluence(2, 0.85, 0.000001, 0)[1].Rank) {
+		t.Error("Expected the contribution of 2 to 3 to match")
+	}
+	if actual := graph.OutInfluence(4, 0.85, 0.000001, 3); len(actual) != 0 {
+		t.Error("Expected no influence from a dangling node but got", actual)
+	}
+}
+
+func TestLinkAfterRank64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+
+	graph.Link(3, 4, 5.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.22824611046948495,
+		2: 0.1680133146238652,
+		3: 0.29388790891145466,
+		4: 0.309852665995195,
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestLastRun64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+
+	run := graph.LastRun
+	if !run.Converged || run.Reason != ReasonConverged {
+		t.Error("Expected a converged run but got", run)
+	}
+	if run.Iterations == 0 || run.Delta > 0.000001 {
+		t.Error("Unexpected run", run)
+	}
+}
+
+func TestLeakedMass64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	calls, last, total := 0, 0.0, 0.0
+	graph.LeakProgress = func(iteration int, leak float64) {
+		calls++
+		last = leak
+		total += leak
+	}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+
+	if calls != graph.LastRun.Iterations {
+		t.Error("Expected", graph.LastRun.Iterations, "calls but got", calls)
+	}
+	if leaked := graph.LeakedMass(); math.Abs(leaked-0.15177668753652385) > 1e-5 || leaked != last {
+		t.Error("Expected the rank of the dangling node but got", leaked, "and", last)
+	}
+	if leaked := graph.LastRun.TotalLeaked; leaked != total || leaked <= last {
+		t.Error("Expected the leak summed over the iterations", total, "but got", leaked)
+	}
+}
+
+func TestLogSpace64(t *testing.T) {
+	graph := NewGraph64()
+	graph.LogSpace = true
+
+	graph.Link(1, 2, -1001.0)
+	graph.Link(1, 3, -1000.0)
+	graph.Link(2, 3, math.Log(0.25))
+	graph.Link(2, 4, math.Log(0.5))
+	graph.Link(2, 4, math.Log(0.25))
+	graph.Link(3, 1, -5.0)
+
+	linear := NewGraph64()
+
+	linear.Link(1, 2, math.Exp(-1))
+	linear.Link(1, 3, 1.0)
+	linear.Link(2, 3, 1.0)
+	linear.Link(2, 4, 3.0)
+	linear.Link(3, 1, 1.0)
+
+	actual, expected := map[uint64]float64{}, map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	linear.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestStallWindow64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 1, 1.0)
+
+	graph.StallWindow = 3
+	graph.RankWarmVector(1, 0.000001, []float64{1, 0}, func(node uint64, rank float64) {})
+
+	if run := graph.LastRun; run.Converged || run.Reason != ReasonStalled || run.Iterations != 4 {
+		t.Error("Expected a stalled run but got", run)
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+	if run := graph.LastRun; !run.Converged {
+		t.Error("Expected a converged run but got", run)
+	}
+}
+
+func TestAccelerationQuadratic64(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	random := NewGraph64()
+	for i := 0; i < 3000; i++ {
+		random.Link(uint64(rng.Intn(500)), uint64(rng.Intn(500)), rng.Float64())
+	}
+	small := NewGraph64()
+	small.Link(1, 2, 1.0)
+	small.Link(1, 3, 2.0)
+	small.Link(2, 3, 3.0)
+	small.Link(2, 4, 4.0)
+	small.Link(3, 1, 5.0)
+
+	for _, graph := range []*Graph64{small, random, scaleFree64(2000, 3)} {
+		for _, α := range []float64{0.85, 0.99} {
+			graph.Acceleration = AccelerationNone
+			plain := map[uint64]float64{}
+			graph.Rank(α, 0.000000001, func(node uint64, rank float64) {
+				plain[node] = rank
+			})
+			iterations := graph.LastRun.Iterations
+
+			graph.Acceleration = AccelerationQuadratic
+			graph.Rank(α, 0.000000001, func(node uint64, rank float64) {
+				if math.Abs(rank-plain[node]) > 1e-7 {
+					t.Error("Expected", plain[node], "for node", node, "but got", rank)
+				}
+			})
+			if graph.LastRun.Iterations > iterations {
+				t.Error("Expected at most", iterations, "iterations but ran", graph.LastRun.Iterations)
+			}
+		}
+	}
+}
+
+func TestSetTeleport64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 4, 1.0)
+	graph.Link(4, 1, 1.0)
+
+	expected := map[uint64]float64{}
+	graph.RankFromNode(1, 0.5, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	graph.SetTeleport(map[uint64]float64{1: 2, 5: 1})
+	actual := map[uint64]float64{}
+	graph.Rank(0.5, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	// The distribution is reused on a new graph, where node 5 exists.
+	graph.Reset()
+	graph.Link(1, 5, 1.0)
+	graph.Link(5, 1, 1.0)
+	graph.Rank(0.5, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if math.Abs(actual[1]-(2.0/3*0.5+0.5*actual[5])) > 1e-5 {
+		t.Error("Expected node 1 to receive two thirds of the teleport but got", actual)
+	}
+
+	graph.SetTeleport(nil)
+	graph.Rank(0.5, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if math.Abs(actual[1]-0.5) > 1e-5 || math.Abs(actual[5]-0.5) > 1e-5 {
+		t.Error("Expected uniform ranks but got", actual)
+	}
+}
+
+func TestRankBatched64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+	graph.AddNode(5)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	sizes := []int{}
+	graph.RankBatched(0.85, 0.000001, 2, func(batch []Result) {
+		sizes = append(sizes, len(batch))
+		for _, result := range batch {
+			actual[result.ID] = result.Rank
+		}
+	})
+
+	if reflect.DeepEqual(sizes, []int{2, 2, 1}) != true {
+		t.Error("Expected batches of 2, 2 and 1 results but got", sizes)
+	}
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestMinRank64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	rest := 0.34983779905464363 + 0.3295121849483849
+	expected := map[uint64]float64{
+		1: 0.6 * 0.34983779905464363 / rest,
+		2: 0.2,
+		3: 0.6 * 0.3295121849483849 / rest,
+		4: 0.2,
+	}
+
+	graph.MinRank = 0.2
+	actual := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	graph.MinRank = 0.3
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		if math.Abs(rank-0.25) > 1e-9 {
+			t.Error("Expected uniform ranks but got", rank, "for node", node)
+		}
+	})
+}
+
+func TestRankQuery64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	expected := map[uint64]float64{
+		2: 0.1688733284604475,
+		4: 0.15177668753652385,
+	}
+	actual := graph.RankQuery([]uint64{2, 4, 5}, 0.85, 0.000001)
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestRankSchedule64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.34983779905464363,
+		2: 0.1688733284604475,
+		3: 0.3295121849483849,
+		4: 0.15177668753652385,
+	}
+
+	graph.RankSchedule([]float64{0.5, 0.6, 0.7, 0.8, 0.85}, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	graph.SetDamping(4, 0.5)
+	graph.RankSchedule([]float64{0.5, 0.85}, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestRankAgainst64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 1, 1.0)
+
+	baseline := map[uint64]float64{1: 0.5, 2: 0.25, 4: 0.25}
+	ranks, deltas := map[uint64]float64{}, map[uint64]float64{}
+	graph.RankAgainst(baseline, 0.85, 0.000001, func(node uint64, rank, delta float64) {
+		ranks[node], deltas[node] = rank, delta
+	})
+
+	expectedRanks := map[uint64]float64{1: 1.0 / 3, 2: 1.0 / 3, 3: 1.0 / 3, 4: 0}
+	expectedDeltas := map[uint64]float64{1: 1.0/3 - 0.5, 2: 1.0/3 - 0.25, 3: 1.0 / 3, 4: -0.25}
+	if reflect.DeepEqual(convert64(ranks), convert64(expectedRanks)) != true {
+		t.Error("Expected", expectedRanks, "but got", ranks)
+	}
+	if reflect.DeepEqual(convert64(deltas), convert64(expectedDeltas)) != true {
+		t.Error("Expected", expectedDeltas, "but got", deltas)
+	}
+}
+
+func TestSubnormalOutbound64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(1, 2, 5e-324)
+	graph.Link(1, 3, 1e-320)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 1, 1.0)
+	graph.Link(3, 2, 1.0)
+
+	dangling := NewGraph64()
+	dangling.AddNode(1)
+	dangling.Link(2, 3, 1.0)
+	dangling.Link(3, 1, 1.0)
+	dangling.Link(3, 2, 1.0)
+
+	for _, deterministic := range []bool{false, true} {
+		graph.Deterministic, dangling.Deterministic = deterministic, deterministic
+		actual, expected := map[uint64]float64{}, map[uint64]float64{}
+		graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+			if math.IsNaN(rank) || math.IsInf(rank, 0) {
+				t.Error("Expected a finite rank for", node, "but got", rank)
+			}
+			actual[node] = rank
+		})
+		dangling.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+			expected[node] = rank
+		})
+		if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+			t.Error("Expected", expected, "but got", actual)
+		}
+	}
+	if nodes := graph.DanglingNodes(); !reflect.DeepEqual(nodes, []uint64{1}) {
+		t.Error("Expected node 1 to be dangling but got", nodes)
+	}
+}
+
+func TestMaxEdgeContribution64(t *testing.T) {
+	farm := func(graph *Graph64) {
+		for i := uint64(10); i < 20; i++ {
+			graph.Link(i, 1, 1.0)
+		}
+		graph.Link(1, 2, 1.0)
+		graph.Link(2, 1, 1.0)
+		graph.Link(3, 4, 1.0)
+		graph.Link(4, 3, 1.0)
+	}
+	graph, capped := NewGraph64(), NewGraph64()
+	farm(graph)
+	farm(capped)
+	capped.MaxEdgeContribution = 0.05
+
+	for _, deterministic := range []bool{false, true} {
+		capped.Deterministic = deterministic
+		ranks, clamped := map[uint64]float64{}, map[uint64]float64{}
+		graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+			ranks[node] = rank
+		})
+		sum := float64(0)
+		capped.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+			clamped[node] = rank
+			sum += rank
+		})
+		if math.Abs(sum-1) > 0.000001 {
+			t.Error("Expected the ranks to sum to 1 but got", sum)
+		}
+		if clamped[1] >= ranks[1] || clamped[2] >= ranks[2] {
+			t.Error("Expected the farm to lose rank but got", clamped, "instead of", ranks)
+		}
+		if clamped[3] <= ranks[3] {
+			t.Error("Expected the other nodes to gain rank but got", clamped, "instead of", ranks)
+		}
+	}
+
+	// The clamped rank adds up the same way whatever the number of workers.
+	capped = NewGraph64()
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 4096; i++ {
+		capped.Link(uint64(rng.Intn(256)), uint64(rng.Intn(256)), rng.Float64())
+	}
+	capped.MaxEdgeContribution, capped.Deterministic = 0.0005, true
+	var first []float64
+	for _, workers := range []int{1, 3, 16} {
+		a := capped.iterate(&settings64{α: 0.85, ε: 0.000001, workers: workers})
+		ranks := make([]float64, len(capped.nodes))
+		for i := range capped.nodes {
+			ranks[i] = capped.nodes[i].weight[a]
+		}
+		if first == nil {
+			first = ranks
+		} else if !reflect.DeepEqual(ranks, first) {
+			t.Fatal("Expected identical ranks with", workers, "workers")
+		}
+	}
+}
+
+func TestRankCapped64(t *testing.T) {
+	graph := NewGraph64()
+	for i := uint64(2); i < 8; i++ {
+		graph.Link(i, 1, 1.0)
+		graph.Link(1, i, 0.1)
+	}
+	graph.Link(2, 3, 1.0)
+
+	ranks := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		ranks[node] = rank
+	})
+	if ranks[1] <= 0.3 {
+		t.Fatal("Expected node 1 to dominate but got", ranks)
+	}
+
+	sum, capped := float64(0), map[uint64]float64{}
+	graph.RankCapped(0.3, 0.85, 0.000001, func(node uint64, rank float64) {
+		capped[node] = rank
+		sum += rank
+	})
+	if math.Abs(sum-1) > 0.000001 {
+		t.Error("Expected the ranks to sum to 1 but got", sum)
+	}
+	if math.Abs(capped[1]-0.3) > 0.000001 {
+		t.Error("Expected node 1 to be capped at 0.3 but got", capped[1])
+	}
+	for node, rank := range capped {
+		if node != 1 && rank <= ranks[node] {
+			t.Error("Expected node", node, "to gain rank but got", rank, "instead of", ranks[node])
+		}
+	}
+
+	uniform := map[uint64]float64{}
+	graph.RankCapped(0.1, 0.85, 0.000001, func(node uint64, rank float64) {
+		uniform[node] = rank
+	})
+	for node, rank := range uniform {
+		if math.Abs(rank-1.0/7) > 0.000001 {
+			t.Error("Expected a uniform rank for", node, "but got", rank)
+		}
+	}
+}
+
+func TestRankNormalizedByDegree64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 1, 1.0)
+	graph.AddNode(4)
+
+	ranks := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		ranks[node] = rank
+	})
+
+	in, out := map[uint64]float64{}, map[uint64]float64{}
+	graph.RankNormalizedByDegree(0.85, 0.000001, true, func(node uint64, score float64) {
+		in[node] = score
+	})
+	graph.RankNormalizedByDegree(0.85, 0.000001, false, func(node uint64, score float64) {
+		out[node] = score
+	})
+
+	expectedIn := map[uint64]float64{1: ranks[1], 2: ranks[2], 3: ranks[3] / 2, 4: 0}
+	expectedOut := map[uint64]float64{1: ranks[1] / 2, 2: ranks[2], 3: ranks[3], 4: 0}
+	if reflect.DeepEqual(convert64(in), convert64(expectedIn)) != true {
+		t.Error("Expected", expectedIn, "but got", in)
+	}
+	if reflect.DeepEqual(convert64(out), convert64(expectedOut)) != true {
+		t.Error("Expected", expectedOut, "but got", out)
+	}
+}
+
+func TestResidual64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 1, 1.0)
+	graph.Link(3, 4, 1.0)
+
+	ranks := map[uint64]float64{}
+	graph.Rank(0.85, 0.000000001, func(node uint64, rank float64) {
+		ranks[node] = rank
+	})
+	if residual := graph.Residual(0.85, ranks); residual > 0.00000001 {
+		t.Error("Expected a tiny residual but got", residual)
+	}
+
+	uniform := map[uint64]float64{1: 0.25, 2: 0.25, 3: 0.25, 4: 0.25}
+	if residual := graph.Residual(0.85, uniform); math.Abs(residual-0.389583) > 0.000001 {
+		t.Error("Expected a residual of 0.389583 but got", residual)
+	}
+}
+
+func TestErrorBound64(t *testing.T) {
+	graph := NewGraph64()
+	if bound := graph.ErrorBound(); !math.IsInf(bound, 1) {
+		t.Error("Expected no bound before ranking but got", bound)
+	}
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 1, 1.0)
+	graph.Link(3, 4, 1.0)
+
+	expected := map[uint64]float64{}
+	graph.Rank(0.85, 0.000000000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	actual := map[uint64]float64{}
+	graph.Rank(0.85, 0.001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	distance := float64(0)
+	for id, rank := range expected {
+		distance += math.Abs(actual[id] - rank)
+	}
+	bound := graph.ErrorBound()
+	if want := 0.85 * graph.LastRun.Delta / 0.15; math.Abs(bound-want) > 0.000000001 {
+		t.Error("Expected", want, "but got", bound)
+	}
+	if distance > bound {
+		t.Error("Expected the distance", distance, "to be within", bound)
+	}
+
+	graph.SetRestart(2, 0.05)
+	graph.RestartMode = RestartAbsolute
+	graph.Rank(0.85, 0.001, func(node uint64, rank float64) {})
+	if want, bound := 0.95*graph.LastRun.Delta/0.05, graph.ErrorBound(); math.Abs(bound-want) > 0.000000001 {
+		t.Error("Expected the bound of the restarted node", want, "but got", bound)
+	}
+
+	graph.RankFixed(0.85, 5, func(node uint64, rank float64) {})
+	if bound := graph.ErrorBound(); !math.IsInf(bound, 1) {
+		t.Error("Expected no bound for RankFixed but got", bound)
+	}
+}
+
+func TestRankConstrained64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 1, 1.0)
+	graph.Link(3, 4, 1.0)
+	graph.AddNode(5)
+
+	ranks := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		ranks[node] = rank
+	})
+
+	graph.SetConstraint(3, Constraint{Ceiling: 0.2})
+	graph.SetConstraint(5, Constraint{Floor: 0.1})
+	sum, actual := float64(0), map[uint64]float64{}
+	graph.RankConstrained(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+		sum += rank
+	})
+	if math.Abs(sum-1) > 0.000001 {
+		t.Error("Expected the ranks to sum to 1 but got", sum)
+	}
+	if ranks[3] <= 0.2 || math.Abs(actual[3]-0.2) > 0.000001 {
+		t.Error("Expected node 3 to be capped at 0.2 but got", actual[3], "from", ranks[3])
+	}
+	if ranks[5] >= 0.1 || math.Abs(actual[5]-0.1) > 0.000001 {
+		t.Error("Expected node 5 to be raised to 0.1 but got", actual[5], "from", ranks[5])
+	}
+}
+
+func TestSetRestart64(t *testing.T) {
+	link := func(graph *Graph64) {
+		graph.Link(1, 2, 1.0)
+		graph.Link(1, 3, 2.0)
+		graph.Link(2, 3, 1.0)
+		graph.Link(3, 1, 1.0)
+		graph.Link(3, 4, 1.0)
+	}
+	restarted, damped := NewGraph64(), NewGraph64()
+	link(restarted)
+	link(damped)
+	restarted.SetRestart(3, 0.5)
+	restarted.SetDamping(1, 0.6)
+	restarted.SetRestart(1, 0.5)
+	damped.SetDamping(3, 0.425)
+	damped.SetDamping(1, 0.3)
+
+	actual, expected := map[uint64]float64{}, map[uint64]float64{}
+	sum := float64(0)
+	restarted.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+		sum += rank
+	})
+	damped.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+	if math.Abs(sum-1) > 0.000001 {
+		t.Error("Expected the ranks to sum to 1 but got", sum)
+	}
+
+	// The restart of a dangling node doesn't change the rank it leaks.
+	restarted.SetRestart(4, 0.9)
+	leaked := map[uint64]float64{}
+	restarted.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		leaked[node] = rank
+	})
+	if reflect.DeepEqual(convert64(leaked), convert64(actual)) != true {
+		t.Error("Expected", actual, "but got", leaked)
+	}
+}
+
+func TestSuggestAlpha64(t *testing.T) {
+	graph := scaleFree64(500, 3)
+
+	α := graph.SuggestAlpha(0.000001, 20)
+	if α <= 0 || α >= 1 {
+		t.Fatal("Expected a damping factor in (0, 1) but got", α)
+	}
+	if graph.MaxIterations != 0 {
+		t.Error("Expected MaxIterations to be restored but got", graph.MaxIterations)
+	}
+
+	graph.Rank(α, 0.000001, func(node uint64, rank float64) {})
+	if run := graph.LastRun; !run.Converged || run.Iterations > 20 {
+		t.Error("Expected a convergence within 20 iterations but got", run)
+	}
+	graph.Rank(α+0.01, 0.000001, func(node uint64, rank float64) {})
+	if run := graph.LastRun; run.Iterations <= 20 {
+		t.Error("Expected a higher damping factor to take more than 20 iterations but got", run)
+	}
+}
+
+func TestTopNContext64(t *testing.T) {
+	graph := scaleFree64(500, 3)
+
+	ranks := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		ranks[node] = rank
+	})
+	top, err := graph.TopNContext(context.Background(), 0.85, 0.000001, 5)
+	if err != nil || len(top) != 5 || !graph.LastRun.Converged {
+		t.Fatal("Expected a converged top 5 but got", top, err)
+	}
+	for i, result := range top {
+		if result.Rank != ranks[result.ID] || (i > 0 && result.Rank > top[i-1].Rank) {
+			t.Error("Expected the highest ranks in order but got", top)
+			break
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	graph.Progress = func(iteration int, Δ float64) {
+		if iteration == 3 {
+			cancel()
+		}
+	}
+	partial, err := graph.TopNContext(ctx, 0.85, 0.000001, 5)
+	graph.Progress = nil
+	if err != context.Canceled || graph.LastRun.Converged || graph.LastRun.Iterations != 3 {
+		t.Error("Expected a cancelled ranking after 3 iterations but got", err, graph.LastRun)
+	}
+	if len(partial) != 5 || partial[0].ID != top[0].ID {
+		t.Error("Expected a best effort top 5 but got", partial)
+	}
+}
+
+func TestRestartAbsolute64(t *testing.T) {
+	link := func(graph *Graph64) {
+		graph.Link(1, 2, 1.0)
+		graph.Link(1, 3, 2.0)
+		graph.Link(2, 3, 1.0)
+		graph.Link(3, 1, 1.0)
+		graph.Link(3, 4, 1.0)
+		graph.AddNode(5)
+	}
+	restarted, damped := NewGraph64(), NewGraph64()
+	link(restarted)
+	link(damped)
+	restarted.RestartMode = RestartAbsolute
+	restarted.SetRestart(1, 0.5)
+	restarted.SetDamping(3, 0.6)
+	restarted.SetRestart(3, 0.1)
+	restarted.SetRestart(4, 0.9)
+	damped.SetDamping(1, 0.5)
+	damped.SetDamping(3, 0.9)
+
+	// The dangling nodes 4 and 5 leak all of their rank whatever their restart
+	// probability, and the ranks still sum to 1.
+	actual, expected := map[uint64]float64{}, map[uint64]float64{}
+	sum := float64(0)
+	restarted.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+		sum += rank
+	})
+	damped.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+	if math.Abs(sum-1) > 0.000001 {
+		t.Error("Expected the ranks to sum to 1 but got", sum)
+	}
+	if leaked := restarted.LeakedMass(); math.Abs(leaked-(actual[4]+actual[5])) > 0.000001 {
+		t.Error("Expected the dangling nodes to leak", actual[4]+actual[5], "but got", leaked)
+	}
+}
+
+func TestDenormalizeMixedSign64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(0, 1, -2.0)
+	graph.Link(0, 2, 1.0)
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+	graph.Link(3, 0, 1.0)
+
+	expected := []Edge{{0, 1, -2}, {0, 2, 1}, {3, 0, 1}}
+	if edges := graph.sortedEdges(); !reflect.DeepEqual(edges, expected) {
+		t.Error("Expected", expected, "but got", edges)
+	}
+}
+
+func TestRankFixed64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.3574200148809524,
+		2: 0.1684468005952381,
+		3: 0.31314918154761906,
+		4: 0.16098400297619048,
+	}
+
+	graph.RankFixed(0.85, 2, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+	if run := graph.LastRun; run.Iterations != 2 || run.Converged || run.Reason != ReasonIterations {
+		t.Error("Unexpected run", run)
+	}
+}
+
+func TestRankResumable64(t *testing.T) {
+	build := func() *Graph64 {
+		graph := NewGraph64()
+
+		graph.Link(1, 2, 1.0)
+		graph.Link(1, 3, 2.0)
+		graph.Link(2, 3, 3.0)
+		graph.Link(2, 4, 4.0)
+		graph.Link(3, 1, 5.0)
+
+		return graph
+	}
+
+	var saved []float64
+	graph := build()
+	graph.RankResumable(0.85, 0.000001, 5, func(iter int, weights []float64) {
+		if iter%5 != 0 {
+			t.Error("Unexpected checkpoint at iteration", iter)
+		}
+		if iter == 10 {
+			saved = append([]float64(nil), weights...)
+		}
+	}, nil, func(node uint64, rank float64) {})
+	total := graph.LastRun.Iterations
+
+	if len(saved) != 4 {
+		t.Fatal("Expected a checkpoint at iteration 10")
+	}
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.34983779905464363,
+		2: 0.1688733284604475,
+		3: 0.3295121849483849,
+		4: 0.15177668753652385,
+	}
+
+	graph = build()
+	graph.RankResumable(0.85, 0.000001, 0, nil, saved, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+	if graph.LastRun.Iterations >= total {
+		t.Error("Expected resuming to save iterations but ran", graph.LastRun.Iterations, "of", total)
+	}
+
+	// Node 4 is the only dangling node, so its rank is the leak of the checkpoint.
+	actual = map[uint64]float64{}
+	graph = build()
+	graph.RankResumable(0.85, 0.000001, 0, nil, saved, func(node uint64, rank float64) {
+		actual[node] = rank
+	}, saved[graph.index[4]])
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestSetTeleportable64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	graph.SetTeleportable(4, false)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.3760696965769332,
+		2: 0.18153581406262592,
+		3: 0.35421985483958074,
+		4: 0.08817463452085997,
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	graph.SetTeleportable(4, true)
+
+	expected = map[uint64]float64{
+		1: 0.34983779905464363,
+		2: 0.1688733284604475,
+		3: 0.3295121849483849,
+		4: 0.15177668753652385,
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestTeleportBaseline64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+
+	actual := graph.TeleportBaseline()
+	expected := map[uint64]float64{1: 0.25, 2: 0.25, 3: 0.25, 4: 0.25}
+	if reflect.DeepEqual(actual, expected) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	graph.SetTeleportable(1, false)
+
+	actual = map[uint64]float64{}
+	graph.Rank(0, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	expected = graph.TeleportBaseline()
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+	if expected[1] != 0 {
+		t.Error("Expected no teleport to node 1 but got", expected[1])
+	}
+}
+
+func TestSetDamping64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	graph.SetDamping(3, 0.5)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.2767794087163134,
+		2: 0.18784949757150937,
+		3: 0.3347011652422244,
+		4: 0.20066992846995219,
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestLinkChecked64(t *testing.T) {
+	graph := NewGraph64()
+
+	if err := graph.LinkChecked(1, 2, math.NaN()); err != ErrNonFinite {
+		t.Error("Expected", ErrNonFinite, "but got", err)
+	}
+	if err := graph.LinkChecked(1, 2, math.MaxFloat64); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	if err := graph.LinkChecked(1, 3, math.MaxFloat64); err != ErrNonFinite {
+		t.Error("Expected an overflow to fail but got", err)
+	}
+
+	graph.Link(2, 3, math.Inf(1))
+	graph.Link(2, 3, 1.0)
+	if graph.Rejected != 1 {
+		t.Error("Expected 1 rejected edge but got", graph.Rejected)
+	}
+	if len(graph.index) != 3 {
+		t.Error("Expected 3 nodes but got", len(graph.index))
+	}
+}
+
+func TestStrictNodes64(t *testing.T) {
+	graph := NewGraph64()
+	graph.StrictNodes = true
+
+	graph.AddNode(1)
+	graph.AddNode(2)
+	if err := graph.LinkChecked(1, 2, 1.0); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	if err := graph.LinkChecked(1, 3, 1.0); err != ErrUnknownNode {
+		t.Error("Expected", ErrUnknownNode, "but got", err)
+	}
+	if err := graph.LinkChecked(4, 2, 1.0); err != ErrUnknownNode {
+		t.Error("Expected", ErrUnknownNode, "but got", err)
+	}
+	graph.Link(2, 5, 1.0)
+	if graph.Rejected != 1 {
+		t.Error("Expected 1 rejected edge but got", graph.Rejected)
+	}
+	if len(graph.index) != 2 {
+		t.Error("Expected 2 nodes but got", len(graph.index))
+	}
+}
+
+func TestOnStable64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	stable := map[uint64]float64{}
+	graph.OnStable = func(node uint64, rank float64) {
+		if _, ok := stable[node]; ok {
+			t.Error("Node", node, "reported twice")
+		}
+		stable[node] = rank
+	}
+
+	actual := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(stable), convert64(actual)) != true {
+		t.Error("Expected", actual, "but got", stable)
+	}
+}
+
+func TestResetKeepCapacity64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	capacity := cap(graph.nodes)
+	graph.ResetKeepCapacity()
+	if len(graph.nodes) != 0 || cap(graph.nodes) != capacity {
+		t.Error("Expected an empty graph with a capacity of", capacity)
+	}
+
+	graph.Link(1, 2, 6.0)
+	graph.Link(1, 3, 7.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.25974019022001016,
+		2: 0.3616383883769191,
+		3: 0.3786214214030706,
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func BenchmarkResetKeepCapacity64(b *testing.B) {
+	graph := NewGraph64()
+	for n := 0; n < b.N; n++ {
+		graph.ResetKeepCapacity()
+		for i := uint64(0); i < 1024; i++ {
+			graph.Link(i, (i*7)%1024, 1.0)
+		}
+	}
+}
+
+func TestRankFiltered64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		2: 0.1688733284604475,
+		4: 0.15177668753652385,
+	}
+
+	emit := map[uint64]bool{2: true, 3: false, 4: true, 5: true}
+	graph.RankFiltered(0.85, 0.000001, emit, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestRecordHistory64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 1, 1.0)
+
+	var history bytes.Buffer
+	graph.RecordHistory(&history)
+	graph.RankFixed(0.85, 2, func(node uint64, rank float64) {})
+
+	expected := "1,1,0.5\n1,2,0.5\n2,1,0.5\n2,2,0.5\n"
+	if actual := history.String(); actual != expected {
+		t.Errorf("Expected %q but got %q", expected, actual)
+	}
+
+	graph.RecordHistory(nil)
+	graph.RankFixed(0.85, 2, func(node uint64, rank float64) {})
+	if actual := history.String(); actual != expected {
+		t.Errorf("Expected %q but got %q", expected, actual)
+	}
+}
+
+func TestRankIndexed64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.34983779905464363,
+		2: 0.1688733284604475,
+		3: 0.3295121849483849,
+		4: 0.15177668753652385,
+	}
+
+	graph.RankIndexed(0.85, 0.000001, func(index uint, node uint64, rank float64) {
+		if graph.index[node] != index {
+			t.Error("Expected index", graph.index[node], "for node", node, "but got", index)
+		}
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestDanglingSink64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+	graph.Link(3, 6, 5.0)
+	graph.Link(5, 1, 5.0)
+
+	leak := map[uint64]float64{}
+	graph.Rank(0.85, 0.0000001, func(node uint64, rank float64) {
+		leak[node] = rank
+	})
+
+	graph.DanglingMode = DanglingSink
+	sink, sum := map[uint64]float64{}, float64(0)
+	graph.Rank(0.85, 0.0000001, func(node uint64, rank float64) {
+		sink[node] = rank
+		sum += rank
+	})
+
+	if reflect.DeepEqual(convert64(sink), convert64(leak)) != true {
+		t.Error("Expected", leak, "but got", sink)
+	}
+	if math.Abs(sum-1) > 0.000001 {
+		t.Error("Expected ranks to sum to 1 but got", sum)
+	}
+}
+
+func TestMaxIterations64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	progress := 0
+	graph.MaxIterations = 3
+	graph.Progress = func(iteration int, Δ float64) {
+		progress++
+		if iteration != progress || Δ <= 0 {
+			t.Error("Unexpected progress", iteration, Δ)
+		}
+	}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+
+	run := graph.LastRun
+	if progress != 3 || run.Iterations != 3 || run.Converged || run.Reason != ReasonMaxIterations {
+		t.Error("Unexpected run", run)
+	}
+}
+
+func TestRankRecencyBiased64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	graph.SetTimestamp(1, 100)
+	graph.SetTimestamp(2, 100)
+	graph.SetTimestamp(3, 90)
+	graph.SetTimestamp(4, 110)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.31539157830418935,
+		2: 0.1676526594619053,
+		3: 0.2789412595832296,
+		4: 0.2380145026506757,
+	}
+
+	graph.RankRecencyBiased(0.85, 0.000001, 10, func(node uint64, rank float64) {
 		actual[node] = rank
 	})
 
@@ -54,7 +1489,78 @@ func TestSimple64(t *testing.T) {
 	}
 }
 
-func TestWeighted64(t *testing.T) {
+func TestDeterministic64(t *testing.T) {
+	graph := NewGraph64()
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 4096; i++ {
+		graph.Link(uint64(rng.Intn(256)), uint64(rng.Intn(256)), rng.Float64())
+	}
+
+	expected := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	graph.Deterministic = true
+	var first map[uint64]float64
+	for i := 0; i < 8; i++ {
+		actual := map[uint64]float64{}
+		graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+			actual[node] = rank
+		})
+		if first == nil {
+			first = actual
+		} else if reflect.DeepEqual(actual, first) != true {
+			t.Fatal("Expected identical ranks from every run")
+		}
+	}
+
+	if reflect.DeepEqual(convert64(first), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", first)
+	}
+
+	// The ranks don't depend on the number of CPUs of the machine either.
+	for _, workers := range []int{1, 3, 16} {
+		a := graph.iterate(&settings64{α: 0.85, ε: 0.000001, workers: workers})
+		for id, rank := range first {
+			if graph.nodes[graph.index[id]].weight[a] != rank {
+				t.Fatal("Expected identical ranks with", workers, "workers")
+			}
+		}
+	}
+}
+
+func TestLinkIndexed64(t *testing.T) {
+	graph := NewGraph64()
+
+	var wait sync.WaitGroup
+	indices := make([][2]uint, 64)
+	for i := range indices {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			s, t := graph.LinkIndexed(uint64(i), uint64(i+1), 1.0)
+			indices[i] = [2]uint{s, t}
+		}(i)
+	}
+	wait.Wait()
+
+	if len(graph.index) != 65 {
+		t.Fatal("Expected 65 nodes but got", len(graph.index))
+	}
+	for i, index := range indices {
+		if graph.index[uint64(i)] != index[0] || graph.index[uint64(i+1)] != index[1] {
+			t.Error("Unexpected indices", index, "for edge", i)
+		}
+	}
+
+	if s, t2 := graph.LinkIndexed(100, 101, math.NaN()); graph.index[100] != s || graph.index[101] != t2 {
+		t.Error("Expected the nodes of a rejected edge to be registered")
+	}
+}
+
+func TestRankWarmVector64(t *testing.T) {
 	graph := NewGraph64()
 
 	graph.Link(1, 2, 1.0)
@@ -63,6 +1569,9 @@ func TestWeighted64(t *testing.T) {
 	graph.Link(2, 4, 4.0)
 	graph.Link(3, 1, 5.0)
 
+	initial := graph.RankVector(0.85, 0.000001)
+	cold := graph.LastRun.Iterations
+
 	actual := map[uint64]float64{}
 	expected := map[uint64]float64{
 		1: 0.34983779905464363,
@@ -71,16 +1580,35 @@ func TestWeighted64(t *testing.T) {
 		4: 0.15177668753652385,
 	}
 
-	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+	for node, rank := range expected {
+		if math.Abs(initial[graph.index[node]]-rank) > 0.000001 {
+			t.Error("Expected", rank, "for node", node, "but got", initial[graph.index[node]])
+		}
+	}
+
+	graph.RankWarmVector(0.85, 0.000001, initial, func(node uint64, rank float64) {
 		actual[node] = rank
 	})
 
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+	if warm := graph.LastRun.Iterations; warm >= cold {
+		t.Error("Expected a warm start to take less than", cold, "iterations but took", warm)
+	}
+
+	leak := graph.LeakedMass()
+	actual = map[uint64]float64{}
+	graph.RankWarmVector(0.85, 0.000001, initial, func(node uint64, rank float64) {
+		actual[node] = rank
+	}, leak)
+
 	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
 		t.Error("Expected", expected, "but got", actual)
 	}
 }
 
-func TestDuplicates64(t *testing.T) {
+func TestRankReachable64(t *testing.T) {
 	graph := NewGraph64()
 
 	graph.Link(1, 2, 1.0)
@@ -88,28 +1616,77 @@ func TestDuplicates64(t *testing.T) {
 	graph.Link(2, 3, 3.0)
 	graph.Link(2, 4, 4.0)
 	graph.Link(3, 1, 5.0)
+	graph.Link(4, 5, 5.0)
 
-	graph.Link(1, 2, 6.0)
-	graph.Link(1, 3, 7.0)
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		2: 0.5405407480383627,
+		3: 0.19691110798355876,
+		4: 0.26254814397807835,
+	}
+
+	graph.RankReachable(2, 1, 0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	// With every node reachable, the settings of the graph give the same ranks.
+	graph.SetDamping(3, 0.5)
+	graph.SetRestart(1, 0.2)
+	graph.MaxIterations = 8
+	expected = map[uint64]float64{}
+	graph.RankFromNode(2, 0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+	actual = map[uint64]float64{}
+	graph.RankReachable(2, 10, 0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+	if graph.LastRun.Iterations != 8 {
+		t.Error("Expected 8 iterations but got", graph.LastRun.Iterations)
+	}
+}
+
+func TestRankFromNode64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 4, 1.0)
+	graph.Link(4, 1, 1.0)
 
 	actual := map[uint64]float64{}
 	expected := map[uint64]float64{
-		1: 0.3312334209098247,
-		2: 0.19655848316544225,
-		3: 0.3033555769882879,
-		4: 0.168852518936445,
+		1: 8.0 / 15,
+		2: 4.0 / 15,
+		3: 2.0 / 15,
+		4: 1.0 / 15,
 	}
 
-	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+	graph.RankFromNode(1, 0.5, 0.000001, func(node uint64, rank float64) {
 		actual[node] = rank
 	})
 
 	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
 		t.Error("Expected", expected, "but got", actual)
 	}
+
+	called := false
+	graph.RankFromNode(5, 0.5, 0.000001, func(node uint64, rank float64) {
+		called = true
+	})
+	if called {
+		t.Error("Expected nothing to be ranked for an unknown seed")
+	}
 }
 
-func TestDuplicatesAfterReset64(t *testing.T) {
+func TestRankDecomposed64(t *testing.T) {
 	graph := NewGraph64()
 
 	graph.Link(1, 2, 1.0)
@@ -118,16 +1695,173 @@ func TestDuplicatesAfterReset64(t *testing.T) {
 	graph.Link(2, 4, 4.0)
 	graph.Link(3, 1, 5.0)
 
-	graph.Reset()
+	ranks := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		ranks[node] = rank
+	})
 
-	graph.Link(1, 2, 6.0)
-	graph.Link(1, 3, 7.0)
+	// Every node receives the teleport and the rank leaked by node 4 uniformly.
+	teleport := (1-0.85)/4 + 0.85*ranks[4]/4
+	graph.RankDecomposed(0.85, 0.000001, func(node uint64, fromLinks, fromTeleport float64) {
+		if math.Abs(fromLinks+fromTeleport-ranks[node]) > 1e-6 {
+			t.Error("Expected", ranks[node], "for node", node, "but got", fromLinks+fromTeleport)
+		}
+		if math.Abs(fromTeleport-teleport) > 1e-5 {
+			t.Error("Expected", teleport, "from teleportation for node", node, "but got", fromTeleport)
+		}
+	})
+}
+
+func TestRankUntilOrderStable64(t *testing.T) {
+	graph := scaleFree64(2000, 3)
+
+	expected := map[uint64]float64{}
+	graph.Rank(0.85, 0.000000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+	iterations := graph.LastRun.Iterations
+
+	actual := map[uint64]float64{}
+	graph.RankUntilOrderStable(10, 3, 0.85, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	if run := graph.LastRun; !run.Converged || run.Reason != ReasonOrderStable || run.Iterations >= iterations {
+		t.Error("Expected the order to settle in fewer than", iterations, "iterations but got", run)
+	}
+
+	top := func(ranks map[uint64]float64) []uint64 {
+		ids := make([]uint64, 0, len(ranks))
+		for id := range ranks {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			return ranks[ids[i]] > ranks[ids[j]]
+		})
+		return ids[:10]
+	}
+	if a, b := top(actual), top(expected); !reflect.DeepEqual(a, b) {
+		t.Error("Expected", b, "but got", a)
+	}
+
+	stable := map[uint64]float64{}
+	graph.RankUntilTopKStable(0.85, 10, 3, func(node uint64, rank float64) {
+		stable[node] = rank
+	})
+	if !reflect.DeepEqual(stable, actual) {
+		t.Error("Expected RankUntilTopKStable to match RankUntilOrderStable")
+	}
+}
+
+func TestRankMassByHop64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 4, 1.0)
+	graph.Link(4, 1, 1.0)
+
+	actual := graph.RankMassByHop(1, 0.5, 0.000001, 3)
+	expected := []float64{8.0 / 15, 12.0 / 15, 14.0 / 15, 1}
+	if len(actual) != len(expected) {
+		t.Fatal("Expected", expected, "but got", actual)
+	}
+	for i := range expected {
+		if math.Abs(actual[i]-expected[i]) > 1e-5 {
+			t.Error("Expected", expected, "but got", actual)
+			break
+		}
+	}
+
+	if mass := graph.RankMassByHop(5, 0.5, 0.000001, 3); mass != nil {
+		t.Error("Expected nil for an unknown seed but got", mass)
+	}
+}
+
+func TestInDegree64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+
+	if degree := graph.InDegree(3); degree != 2 {
+		t.Error("Expected an in degree of 2 but got", degree)
+	}
+	if graph.transpose == nil {
+		t.Error("Expected the inbound edges to be cached")
+	}
+
+	graph.Link(4, 3, 1.0)
+	if degree := graph.InDegree(3); degree != 3 {
+		t.Error("Expected an in degree of 3 but got", degree)
+	}
+	if degree := graph.InDegree(1); degree != 0 {
+		t.Error("Expected an in degree of 0 but got", degree)
+	}
+	if degree := graph.InDegree(5); degree != 0 {
+		t.Error("Expected an in degree of 0 for an unknown node but got", degree)
+	}
+
+	graph.InvalidateCache()
+	if graph.transpose != nil {
+		t.Error("Expected the inbound edges to be dropped")
+	}
+}
+
+func TestTransposed64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+
+	graph.Transposed = true
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+	if graph.transpose == nil {
+		t.Error("Expected the inbound edges to be cached")
+	}
+
+	graph.Link(3, 1, 5.0)
+	if graph.transpose != nil {
+		t.Error("Expected the inbound edges to be invalidated")
+	}
 
 	actual := map[uint64]float64{}
 	expected := map[uint64]float64{
-		1: 0.25974019022001016,
-		2: 0.3616383883769191,
-		3: 0.3786214214030706,
+		1: 0.34983779905464363,
+		2: 0.1688733284604475,
+		3: 0.3295121849483849,
+		4: 0.15177668753652385,
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	graph.AddNode(5)
+	if graph.transpose != nil {
+		t.Error("Expected the inbound edges to be invalidated by a new node")
+	}
+}
+
+func TestAddNode64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 1, 1.0)
+	graph.AddNode(3)
+	graph.AddNode(1)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.46511627906976744,
+		2: 0.46511627906976744,
+		3: 0.06976744186046512,
 	}
 
 	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
@@ -157,6 +1891,31 @@ func BenchmarkGraph64(b *testing.B) {
 	}
 }
 
+// scaleFree64 builds a graph by preferential attachment, so a few hubs receive
+// most of the edges.
+func scaleFree64(nodes, degree int) *Graph64 {
+	rng := rand.New(rand.NewSource(1))
+	graph := NewGraph64()
+	targets := []uint64{0}
+	for source := 1; source < nodes; source++ {
+		for i := 0; i < degree; i++ {
+			target := targets[rng.Intn(len(targets))]
+			graph.Link(uint64(source), target, 1.0)
+			targets = append(targets, target)
+		}
+		targets = append(targets, uint64(source))
+	}
+	return graph
+}
+
+func BenchmarkScaleFree64(b *testing.B) {
+	graph := scaleFree64(100000, 8)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		graph.RankFixed(0.85, 10, func(node uint64, rank float64) {})
+	}
+}
+
 func convert32(a map[uint64]float32) map[uint64]int {
 	b := make(map[uint64]int, len(a))
 	for key, value := range a {
@@ -291,9 +2050,86 @@ func TestDuplicatesAfterReset32(t *testing.T) {
 	}
 }
 
+func TestLinkChecked32(t *testing.T) {
+	graph := NewGraph32()
+
+	if err := graph.LinkChecked(1, 2, float32(math.NaN())); err != ErrNonFinite {
+		t.Error("Expected", ErrNonFinite, "but got", err)
+	}
+	if err := graph.LinkChecked(1, 2, math.MaxFloat32); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	if err := graph.LinkChecked(1, 3, math.MaxFloat32); err != ErrNonFinite {
+		t.Error("Expected an overflow to fail but got", err)
+	}
+
+	graph.Link(2, 3, float32(math.Inf(1)))
+	graph.Link(2, 3, 1.0)
+	if graph.Rejected != 1 {
+		t.Error("Expected 1 rejected edge but got", graph.Rejected)
+	}
+}
+
+func TestMaxIterations32(t *testing.T) {
+	graph := NewGraph32()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	progress := 0
+	graph.MaxIterations = 3
+	graph.Progress = func(iteration int, Δ float32) {
+		progress++
+		if iteration != progress || Δ <= 0 {
+			t.Error("Unexpected progress", iteration, Δ)
+		}
+	}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float32) {})
+
+	run := graph.LastRun
+	if progress != 3 || run.Iterations != 3 || run.Converged || run.Reason != ReasonMaxIterations {
+		t.Error("Unexpected run", run)
+	}
+
+	graph = NewGraph32()
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 1, 1.0)
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float32) {})
+	if run := graph.LastRun; run.Iterations == 0 || !run.Converged || run.Reason != ReasonConverged {
+		t.Error("Unexpected run", run)
+	}
+}
+
+func TestAddNode32(t *testing.T) {
+	graph := NewGraph32()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 1, 1.0)
+	graph.AddNode(3)
+	graph.AddNode(1)
+
+	actual := map[uint64]float32{}
+	expected := map[uint64]float32{
+		1: 0.46511627906976744,
+		2: 0.46511627906976744,
+		3: 0.06976744186046512,
+	}
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float32) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert32(actual), convert32(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
 func BenchmarkGraph32(b *testing.B) {
 	for n := 0; n < b.N; n++ {
-		graph := NewGraph64()
+		graph := NewGraph32()
 
 		graph.Link(1, 2, 1.0)
 		graph.Link(1, 3, 2.0)
@@ -301,10 +2137,171 @@ func BenchmarkGraph32(b *testing.B) {
 		graph.Link(2, 4, 4.0)
 		graph.Link(3, 1, 5.0)
 
-		results := map[uint64]float64{}
+		results := map[uint64]float32{}
 
-		graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		graph.Rank(0.85, 0.000001, func(node uint64, rank float32) {
 			results[node] = rank
 		})
 	}
 }
+
+// benchmarkSizes are the numbers of edges of the benchmark graphs.
+var benchmarkSizes = []struct {
+	name  string
+	edges int
+}{
+	{"1K", 1000},
+	{"100K", 100000},
+	{"1M", 1000000},
+}
+
+// benchmarkEdges returns a random graph with an average of 8 edges per node.
+func benchmarkEdges(edges int) [][2]uint64 {
+	rng := rand.New(rand.NewSource(1))
+	nodes := edges/8 + 1
+	links := make([][2]uint64, edges)
+	for i := range links {
+		links[i] = [2]uint64{uint64(rng.Intn(nodes)), uint64(rng.Intn(nodes))}
+	}
+	return links
+}
+
+func BenchmarkLink64(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		links := benchmarkEdges(size.edges)
+		b.Run(size.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				graph := NewGraph64()
+				for _, link := range links {
+					graph.Link(link[0], link[1], 1.0)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLinkPresized64(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		links := benchmarkEdges(size.edges)
+		b.Run(size.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				graph := NewGraph64(size.edges/8 + 1)
+				for _, link := range links {
+					graph.Link(link[0], link[1], 1.0)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkBuildFromChannel64(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		links := benchmarkEdges(size.edges)
+		b.Run(size.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				edges := make(chan Edge, 1024)
+				go func() {
+					for _, link := range links {
+						edges <- Edge{Source: link[0], Target: link[1], Weight: 1.0}
+					}
+					close(edges)
+				}()
+				graph := NewGraph64()
+				graph.BuildFromChannel(edges)
+			}
+		})
+	}
+}
+
+func BenchmarkRank64(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		links := benchmarkEdges(size.edges)
+		b.Run(size.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				graph := NewGraph64()
+				for _, link := range links {
+					graph.Link(link[0], link[1], 1.0)
+				}
+				b.StartTimer()
+				graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+			}
+		})
+	}
+}
+
+func BenchmarkNormalize64(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		links := benchmarkEdges(size.edges)
+		graph := NewGraph64()
+		for _, link := range links {
+			graph.Link(link[0], link[1], 1.0)
+		}
+		b.Run(size.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				graph.normalize()
+				b.StopTimer()
+				graph.denormalize()
+				b.StartTimer()
+			}
+		})
+	}
+
+	b.Run("10M", func(b *testing.B) {
+		if testing.Short() {
+			b.Skip("skipping the 10M node graph in short mode")
+		}
+		// 10M nodes with 2 edges each, a ring and a random edge, fit in memory.
+		const nodes = 10000000
+		rng := rand.New(rand.NewSource(1))
+		graph := NewGraph64(nodes)
+		for i := 0; i < nodes; i++ {
+			graph.Link(uint64(i), uint64((i+1)%nodes), 1.0)
+			graph.Link(uint64(i), uint64(rng.Intn(nodes)), 1.0)
+		}
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			graph.normalize()
+			b.StopTimer()
+			graph.denormalize()
+			b.StartTimer()
+		}
+	})
+}
+
+func BenchmarkLink32(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		links := benchmarkEdges(size.edges)
+		b.Run(size.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				graph := NewGraph32()
+				for _, link := range links {
+					graph.Link(link[0], link[1], 1.0)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRank32(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		links := benchmarkEdges(size.edges)
+		b.Run(size.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				graph := NewGraph32()
+				for _, link := range links {
+					graph.Link(link[0], link[1], 1.0)
+				}
+				b.StartTimer()
+				graph.Rank(0.85, 0.000001, func(node uint64, rank float32) {})
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package pagerank
+
+import "math"
+
+// UpdatePartition runs the update step of an iteration for the nodes with an
+// internal index in [start, end): it returns the rank the edges of these nodes
+// carry to every target, ranks[source] times the normalized weight of the edge,
+// before damping. The partitions of an iteration can be computed on different
+// machines, given the graph and ranks, and merged by CombinePartitions. ranks is
+// indexed by internal index, like the slice returned by RankVector.
+func (g *Graph64) UpdatePartition(start, end uint, ranks []float64) (partialContributions map[uint]float64) {
+	g.normalize()
+	if end > uint(len(g.nodes)) {
+		end = uint(len(g.nodes))
+	}
+	partialContributions = make(map[uint]float64)
+	for source := start; source < end; source++ {
+		node := &g.nodes[source]
+		if node.dangling() {
+			continue
+		}
+		for target, weight := range node.edges {
+			partialContributions[target] += ranks[source] * weight
+		}
+	}
+	return partialContributions
+}
+
+// CombinePartitions merges the partial contributions of the partitions of an
+// iteration, from UpdatePartition, into the ranks of the next iteration: the
+// contributions are damped by α, and the teleport probability and the rank of
+// the dangling nodes in ranks are spread along the teleport distribution. The
+// damping factors set by SetDamping and SetRestart are not used.
+func (g *Graph64) CombinePartitions(α float64, ranks []float64, partials ...map[uint]float64) []float64 {
+	g.normalize()
+	nodes := g.nodes
+	leak := float64(0)
+	for i := range nodes {
+		if nodes[i].dangling() {
+			leak += ranks[i]
+		}
+	}
+	mass, teleport := (1-α)+α*leak, g.teleport(nil)
+	inverse := 1 / float64(len(nodes))
+
+	next := make([]float64, len(nodes))
+	for i := range next {
+		if teleport != nil {
+			next[i] = mass * teleport[i]
+		} else {
+			next[i] = mass * inverse
+		}
+	}
+	for _, partial := range partials {
+		for target, contribution := range partial {
+			next[target] += α * contribution
+		}
+	}
+	return next
+}
+
+// RankPartitioned computes the PageRank of every node like Rank, running every
+// iteration as the given number of partitions with UpdatePartition and
+// CombinePartitions. It is the reference for a distributed driver, and runs the
+// partitions one after the other.
+func (g *Graph64) RankPartitioned(partitions int, α, ε float64, callback func(id uint64, rank float64)) {
+	n := uint(len(g.nodes))
+	if partitions < 1 {
+		partitions = 1
+	}
+	size := (n + uint(partitions) - 1) / uint(partitions)
+
+	ranks := make([]float64, n)
+	for i := range ranks {
+		ranks[i] = 1 / float64(n)
+	}
+	partials := make([]map[uint]float64, 0, partitions)
+	Δ, iterations := float64(1), 0
+	for n > 0 && Δ > ε {
+		if g.MaxIterations > 0 && iterations >= g.MaxIterations {
+			break
+		}
+		partials = partials[:0]
+		for start := uint(0); start < n; start += size {
+			partials = append(partials, g.UpdatePartition(start, start+size, ranks))
+		}
+		next := g.CombinePartitions(α, ranks, partials...)
+		Δ = 0
+		for i := range next {
+			Δ += math.Abs(next[i] - ranks[i])
+		}
+		ranks = next
+		iterations++
+	}
+
+	reason := ReasonConverged
+	if Δ > ε && n > 0 {
+		reason = ReasonMaxIterations
+	}
+	g.LastRun = Run64{
+		Iterations: iterations,
+		Delta:      Δ,
+		Converged:  reason == ReasonConverged,
+		Reason:     reason,
+	}
+
+	for key, value := range g.index {
+		callback(key, ranks[value])
+	}
+}
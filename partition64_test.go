@@ -0,0 +1,37 @@
+package pagerank
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRankPartitioned64(t *testing.T) {
+	graph := scaleFree64(500, 3)
+	graph.AddNode(1000)
+
+	expected := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	for _, partitions := range []int{1, 3, 7} {
+		actual := map[uint64]float64{}
+		graph.RankPartitioned(partitions, 0.85, 0.000001, func(node uint64, rank float64) {
+			actual[node] = rank
+		})
+		if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+			t.Error("Expected the same ranks with", partitions, "partitions")
+		}
+		if !graph.LastRun.Converged {
+			t.Error("Expected the ranking to converge but got", graph.LastRun)
+		}
+	}
+
+	ranks := graph.RankVector(0.85, 0.000001)
+	partial := graph.UpdatePartition(10, 20, ranks)
+	for target, contribution := range partial {
+		if contribution <= 0 || target >= uint(len(ranks)) {
+			t.Error("Expected a positive contribution but got", contribution, "for", target)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package pagerank
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestQuantize64(t *testing.T) {
+	build := func() *Graph64 {
+		graph := NewGraph64()
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 4096; i++ {
+			graph.Link(uint64(rng.Intn(256)), uint64(rng.Intn(256)), rng.Float64())
+		}
+		return graph
+	}
+
+	expected := map[uint64]float64{}
+	build().Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	tolerances := map[int]float64{4: 0.05, 8: 0.005, 16: 0.00001}
+	for bits, tolerance := range tolerances {
+		graph := build()
+		graph.Quantize(bits)
+		if !graph.quantized || graph.nodes[0].edges != nil {
+			t.Fatal("Expected the graph to be quantized")
+		}
+
+		maximum := float64(0)
+		graph.RankQuantized(0.85, 0.000001, func(node uint64, rank float64) {
+			maximum = math.Max(maximum, math.Abs(rank-expected[node])/expected[node])
+		})
+		if maximum > tolerance {
+			t.Error("Expected a relative error below", tolerance, "with", bits, "bits but got", maximum)
+		}
+
+		quantized := map[uint64]float64{}
+		graph.RankQuantized(0.85, 0.000001, func(node uint64, rank float64) {
+			quantized[node] = rank
+		})
+		graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+			if math.Abs(rank-quantized[node]) > 0.000001 {
+				t.Error("Expected", quantized[node], "for node", node, "but got", rank)
+			}
+		})
+		if graph.quantized {
+			t.Error("Expected Rank to restore the edge weights")
+		}
+	}
+}
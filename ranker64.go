@@ -0,0 +1,63 @@
+package pagerank
+
+import "sync"
+
+// Ranker ranks many graphs concurrently while sharing a bounded number of
+// workers between them. Each graph is ranked by a single worker, so that the
+// parallelism comes from ranking several graphs at once rather than from the
+// NumCPU goroutines every Rank uses, which would oversubscribe the CPUs. A Ranker
+// is safe for concurrent use.
+type Ranker struct {
+	workers chan struct{}
+}
+
+// NewRanker returns a Ranker running at most workers rankings at once, NumCPU if
+// workers isn't positive.
+func NewRanker(workers int) *Ranker {
+	if workers <= 0 {
+		workers = NumCPU
+	}
+	return &Ranker{
+		workers: make(chan struct{}, workers),
+	}
+}
+
+// Rank waits for a worker, then computes and returns the PageRank of every node of
+// the graph like Graph64.Rank.
+func (r *Ranker) Rank(g *Graph64, α, ε float64) map[uint64]float64 {
+	r.workers <- struct{}{}
+	defer func() { <-r.workers }()
+
+	a := g.iterate(&settings64{α: α, ε: ε, workers: 1})
+
+	ranks := make(map[uint64]float64, len(g.index))
+	for key, value := range g.index {
+		ranks[key] = g.nodes[value].weight[a]
+	}
+	return ranks
+}
+
+// RankAll ranks every graph and returns their ranks in the same order. A graph
+// given more than once is ranked once, as it can't be ranked concurrently, and its
+// ranks are the same map at each of its positions.
+func (r *Ranker) RankAll(graphs []*Graph64, α, ε float64) []map[uint64]float64 {
+	ranks := make([]map[uint64]float64, len(graphs))
+	first := make(map[*Graph64]int, len(graphs))
+	var wait sync.WaitGroup
+	for i, g := range graphs {
+		if _, ok := first[g]; ok {
+			continue
+		}
+		first[g] = i
+		wait.Add(1)
+		go func(i int, g *Graph64) {
+			defer wait.Done()
+			ranks[i] = r.Rank(g, α, ε)
+		}(i, g)
+	}
+	wait.Wait()
+	for i, g := range graphs {
+		ranks[i] = ranks[first[g]]
+	}
+	return ranks
+}
@@ -0,0 +1,37 @@
+package pagerank
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRanker64(t *testing.T) {
+	graphs := make([]*Graph64, 16)
+	for i := range graphs {
+		graph := NewGraph64()
+
+		graph.Link(1, 2, 1.0)
+		graph.Link(1, 3, 2.0)
+		graph.Link(2, 3, 3.0)
+		graph.Link(2, 4, 4.0)
+		graph.Link(3, 1, 5.0)
+
+		graphs[i] = graph
+	}
+
+	expected := map[uint64]float64{
+		1: 0.34983779905464363,
+		2: 0.1688733284604475,
+		3: 0.3295121849483849,
+		4: 0.15177668753652385,
+	}
+
+	// The same graph can be given more than once.
+	graphs = append(graphs, graphs[0], graphs[0])
+	ranker := NewRanker(3)
+	for i, actual := range ranker.RankAll(graphs, 0.85, 0.000001) {
+		if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+			t.Error("Expected", expected, "for graph", i, "but got", actual)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package pagerank
+
+import (
+	"context"
+	"sync"
+)
+
+// RankSession holds the state of a ranking of a Graph64 that can be paused and
+// resumed. Alpha and Epsilon can be changed between runs.
+type RankSession struct {
+	Alpha    float64
+	Epsilon  float64
+	graph    *Graph64
+	mutex    sync.Mutex
+	weights  []float64
+	teleport []float64
+}
+
+// NewSession returns a new ranking session for the graph.
+// The graph must not be ranked or modified while the session runs.
+func (g *Graph64) NewSession(α, ε float64) *RankSession {
+	return &RankSession{
+		Alpha:   α,
+		Epsilon: ε,
+		graph:   g,
+	}
+}
+
+// Run iterates until the ranks converge or ctx is done, continuing from where the
+// previous run stopped. It returns ctx.Err() if the run was cancelled.
+// The outcome of the run is recorded in the LastRun of the graph.
+func (s *RankSession) Run(ctx context.Context) error {
+	g := s.graph
+	settings := settings64{α: s.Alpha, ε: s.Epsilon, ctx: ctx}
+
+	s.mutex.Lock()
+	if len(s.weights) == len(g.nodes) {
+		settings.initial = append([]float64(nil), s.weights...)
+	} else {
+		s.weights = make([]float64, len(g.nodes))
+	}
+	if len(s.teleport) == len(g.nodes) {
+		settings.teleport = append([]float64(nil), s.teleport...)
+	}
+	s.mutex.Unlock()
+
+	snapshot := func(a int) {
+		s.mutex.Lock()
+		for i := range g.nodes {
+			s.weights[i] = g.nodes[i].weight[a]
+		}
+		s.mutex.Unlock()
+	}
+	settings.after = func(iterations, a int) {
+		snapshot(a)
+	}
+	snapshot(g.iterate(&settings))
+
+	if g.LastRun.Reason == ReasonCancelled {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// AmplifyFocus multiplies the teleport probability of the given nodes by factor
+// and renormalizes the teleport distribution, which starts uniform. Successive
+// calls compound, gradually biasing the ranking toward a growing focus set. The
+// next Run continues from the current ranks rather than starting over, so it
+// only takes the iterations needed to settle on the new distribution. Unknown
+// ids are ignored.
+func (s *RankSession) AmplifyFocus(ids []uint64, factor float64) {
+	g := s.graph
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.teleport) != len(g.nodes) {
+		s.teleport = make([]float64, len(g.nodes))
+		for i := range s.teleport {
+			s.teleport[i] = 1 / float64(len(g.nodes))
+		}
+	}
+	for _, id := range ids {
+		if i, ok := g.index[id]; ok {
+			s.teleport[i] *= factor
+		}
+	}
+	sum := float64(0)
+	for _, p := range s.teleport {
+		sum += p
+	}
+	if sum > 0 {
+		for i := range s.teleport {
+			s.teleport[i] /= sum
+		}
+	}
+}
+
+// Weights returns the current ranks of the session. It is safe to call while the
+// session runs, in which case it returns the ranks of the last iteration.
+func (s *RankSession) Weights() map[uint64]float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	weights := make(map[uint64]float64, len(s.weights))
+	if len(s.weights) == 0 {
+		return weights
+	}
+	for key, value := range s.graph.index {
+		weights[key] = s.weights[value]
+	}
+	return weights
+}
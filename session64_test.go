@@ -0,0 +1,92 @@
+package pagerank
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRankSession64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {})
+	fresh := graph.LastRun.Iterations
+
+	session := graph.NewSession(0.85, 0.000001)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := session.Run(ctx); err != context.Canceled {
+		t.Error("Expected", context.Canceled, "but got", err)
+	}
+	if graph.LastRun.Converged || graph.LastRun.Reason != ReasonCancelled {
+		t.Error("Unexpected run", graph.LastRun)
+	}
+	expected := map[uint64]float64{1: 0.25, 2: 0.25, 3: 0.25, 4: 0.25}
+	if actual := session.Weights(); reflect.DeepEqual(actual, expected) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	session.Epsilon = 0.01
+	if err := session.Run(context.Background()); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+
+	session.Epsilon = 0.000001
+	if err := session.Run(context.Background()); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	if !graph.LastRun.Converged || graph.LastRun.Iterations >= fresh {
+		t.Error("Expected the session to continue but got", graph.LastRun)
+	}
+
+	expected = map[uint64]float64{
+		1: 0.34983779905464363,
+		2: 0.1688733284604475,
+		3: 0.3295121849483849,
+		4: 0.15177668753652385,
+	}
+	if actual := session.Weights(); reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestAmplifyFocus64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 4, 1.0)
+	graph.Link(4, 1, 1.0)
+	graph.Link(4, 2, 1.0)
+
+	session := graph.NewSession(0.85, 0.000001)
+	if err := session.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	session.AmplifyFocus([]uint64{1, 5}, 3)
+	session.AmplifyFocus([]uint64{1, 3}, 2)
+	if err := session.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	continued := graph.LastRun.Iterations
+
+	teleport := []float64{6.0 / 10, 1.0 / 10, 2.0 / 10, 1.0 / 10}
+	a := graph.iterate(&settings64{α: 0.85, ε: 0.000001, teleport: teleport})
+	expected := map[uint64]float64{}
+	for key, value := range graph.index {
+		expected[key] = graph.nodes[value].weight[a]
+	}
+	if actual := session.Weights(); reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+	if continued >= graph.LastRun.Iterations {
+		t.Error("Expected the session to continue in fewer than", graph.LastRun.Iterations, "iterations but ran", continued)
+	}
+}
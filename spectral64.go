@@ -0,0 +1,114 @@
+package pagerank
+
+import (
+	"math"
+	"math/rand"
+)
+
+// SecondEigenvector computes the left eigenvector of the random walk with the
+// second largest eigenvalue, by power iteration deflated against the stationary
+// distribution, and returns its component for every node. The rank of dangling
+// nodes moves uniformly, like with DanglingLeak. The sign of the components
+// partitions the graph into the two sets of nodes between which the walk mixes
+// the slowest, which is the basis of spectral clustering.
+//
+// The walk is made lazy, staying put with probability 1/2, which keeps the
+// eigenvectors but makes the eigenvalue closest to 1 the dominant one. The
+// eigenvector is scaled to unit length. Since eigenvalues of directed graphs can
+// be complex, the iteration may not converge; MaxIterations bounds it.
+func (g *Graph64) SecondEigenvector(ε float64) map[uint64]float64 {
+	g.normalize()
+	nodes := g.nodes
+	n := len(nodes)
+	vector := make(map[uint64]float64, n)
+	if n == 0 {
+		return vector
+	}
+	inverse := 1 / float64(n)
+
+	// step moves x one step along the lazy walk into y.
+	step := func(x, y []float64) {
+		leak := float64(0)
+		for i := range y {
+			y[i] = 0
+		}
+		for source := range nodes {
+			if nodes[source].dangling() {
+				leak += x[source]
+				continue
+			}
+			for target, weight := range nodes[source].edges {
+				y[target] += x[source] * weight
+			}
+		}
+		for i := range y {
+			y[i] = (x[i] + y[i] + leak*inverse) / 2
+		}
+	}
+
+	// The stationary distribution is the eigenvector of eigenvalue 1.
+	stationary, next := make([]float64, n), make([]float64, n)
+	for i := range stationary {
+		stationary[i] = inverse
+	}
+	Δ := float64(1.0)
+	for Δ > ε {
+		step(stationary, next)
+		Δ = 0
+		for i := range next {
+			Δ += math.Abs(next[i] - stationary[i])
+		}
+		stationary, next = next, stationary
+	}
+
+	// Every other left eigenvector sums to 0, so its projection on the
+	// stationary distribution is removed along the sum.
+	deflate := func(x []float64) {
+		sum, norm := float64(0), float64(0)
+		for _, value := range x {
+			sum += value
+		}
+		for i := range x {
+			x[i] -= sum * stationary[i]
+			norm += x[i] * x[i]
+		}
+		if norm = math.Sqrt(norm); norm > 0 {
+			for i := range x {
+				x[i] /= norm
+			}
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	eigenvector := make([]float64, n)
+	for i := range eigenvector {
+		eigenvector[i] = rng.Float64()
+	}
+	deflate(eigenvector)
+	Δ, iterations, reason := float64(1.0), 0, ReasonConverged
+	for Δ > ε {
+		if g.MaxIterations > 0 && iterations >= g.MaxIterations {
+			reason = ReasonMaxIterations
+			break
+		}
+		step(eigenvector, next)
+		deflate(next)
+		Δ = 0
+		for i := range next {
+			Δ += math.Abs(next[i] - eigenvector[i])
+		}
+		eigenvector, next = next, eigenvector
+		iterations++
+	}
+	g.LastRun = Run64{
+		Iterations: iterations,
+		Delta:      Δ,
+		Converged:  reason == ReasonConverged,
+		Reason:     reason,
+	}
+
+	for key, value := range g.index {
+		vector[key] = eigenvector[value]
+	}
+	return vector
+}
@@ -0,0 +1,40 @@
+package pagerank
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSecondEigenvector64(t *testing.T) {
+	graph := NewGraph64()
+
+	// Two triangles joined by a weak edge in each direction.
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 1, 1.0)
+	graph.Link(4, 5, 1.0)
+	graph.Link(5, 6, 1.0)
+	graph.Link(6, 4, 1.0)
+	graph.Link(1, 4, 0.1)
+	graph.Link(4, 1, 0.1)
+
+	vector := graph.SecondEigenvector(0.000000001)
+	if !graph.LastRun.Converged {
+		t.Fatal("Expected the iteration to converge but got", graph.LastRun)
+	}
+
+	norm := float64(0)
+	for _, value := range vector {
+		norm += value * value
+	}
+	if math.Abs(norm-1) > 1e-6 {
+		t.Error("Expected a unit vector but got a squared norm of", norm)
+	}
+
+	sign := func(id uint64) bool {
+		return vector[id] > 0
+	}
+	if sign(1) != sign(2) || sign(1) != sign(3) || sign(4) != sign(5) || sign(4) != sign(6) || sign(1) == sign(4) {
+		t.Error("Expected the triangles to be separated but got", vector)
+	}
+}
@@ -0,0 +1,292 @@
+package pagerank
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// EdgeStore holds the weighted edges of a graph between nodes given by index, so
+// that StoreGraph64 can rank graphs kept in other data structures than maps, such
+// as sorted slices, compressed sparse rows or external memory.
+type EdgeStore interface {
+	// Add adds weight to the edge from source to target, creating it if needed.
+	Add(source, target uint, weight float64)
+	// Neighbors calls visit with every outbound edge of source.
+	Neighbors(source uint, visit func(target uint, weight float64))
+	// OutSum returns the sum of the weights of the outbound edges of source.
+	OutSum(source uint) float64
+}
+
+// MapEdgeStore is the default EdgeStore, which keeps the edges of every node in a
+// map like Graph64.
+type MapEdgeStore struct {
+	edges []map[uint]float64
+	sums  []float64
+}
+
+// NewMapEdgeStore returns a new empty MapEdgeStore.
+func NewMapEdgeStore() *MapEdgeStore {
+	return &MapEdgeStore{}
+}
+
+// Add adds weight to the edge from source to target.
+func (m *MapEdgeStore) Add(source, target uint, weight float64) {
+	for uint(len(m.edges)) <= source {
+		m.edges, m.sums = append(m.edges, nil), append(m.sums, 0)
+	}
+	if m.edges[source] == nil {
+		m.edges[source] = make(map[uint]float64)
+	}
+	m.edges[source][target] += weight
+	m.sums[source] += weight
+}
+
+// Neighbors calls visit with every outbound edge of source, in no given order.
+func (m *MapEdgeStore) Neighbors(source uint, visit func(target uint, weight float64)) {
+	if source >= uint(len(m.edges)) {
+		return
+	}
+	for target, weight := range m.edges[source] {
+		visit(target, weight)
+	}
+}
+
+// OutSum returns the sum of the weights of the outbound edges of source.
+func (m *MapEdgeStore) OutSum(source uint) float64 {
+	if source >= uint(len(m.sums)) {
+		return 0
+	}
+	return m.sums[source]
+}
+
+// SortedEdgeStore is an EdgeStore that keeps the edges of every node in a slice
+// sorted by target, which takes less memory than maps and visits the edges in a
+// fixed order, at the cost of slower insertions.
+type SortedEdgeStore struct {
+	edges [][]link64
+	sums  []float64
+}
+
+// NewSortedEdgeStore returns a new empty SortedEdgeStore.
+func NewSortedEdgeStore() *SortedEdgeStore {
+	return &SortedEdgeStore{}
+}
+
+// Add adds weight to the edge from source to target.
+func (s *SortedEdgeStore) Add(source, target uint, weight float64) {
+	for uint(len(s.edges)) <= source {
+		s.edges, s.sums = append(s.edges, nil), append(s.sums, 0)
+	}
+	edges := s.edges[source]
+	i := sort.Search(len(edges), func(i int) bool { return edges[i].node >= target })
+	if i < len(edges) && edges[i].node == target {
+		edges[i].weight += weight
+	} else {
+		edges = append(edges, link64{})
+		copy(edges[i+1:], edges[i:])
+		edges[i] = link64{node: target, weight: weight}
+		s.edges[source] = edges
+	}
+	s.sums[source] += weight
+}
+
+// Neighbors calls visit with every outbound edge of source, sorted by target.
+func (s *SortedEdgeStore) Neighbors(source uint, visit func(target uint, weight float64)) {
+	if source >= uint(len(s.edges)) {
+		return
+	}
+	for _, edge := range s.edges[source] {
+		visit(edge.node, edge.weight)
+	}
+}
+
+// OutSum returns the sum of the weights of the outbound edges of source.
+func (s *SortedEdgeStore) OutSum(source uint) float64 {
+	if source >= uint(len(s.sums)) {
+		return 0
+	}
+	return s.sums[source]
+}
+
+// StoreGraph64 is a graph whose edges are kept in an EdgeStore. It maps the node
+// ids to indices and ranks the graph through the store; the other features of
+// Graph64 need its own edge maps.
+type StoreGraph64 struct {
+	Verbose bool
+	LastRun Run64
+	// Rejected counts the edges Link skipped because their weight is not finite.
+	Rejected int
+	// MaxIterations, when positive, caps the number of iterations of a ranking,
+	// which is otherwise capped at powerIterations.
+	MaxIterations int
+	// Progress, when set, is called at the end of every iteration of a ranking
+	// with the iteration count and Δ.
+	Progress func(iteration int, Δ float64)
+	store    EdgeStore
+	index    map[uint64]uint
+	ids      []uint64
+}
+
+// NewStoreGraph64 returns a new graph keeping its edges in store, which must be
+// empty. A nil store is a new MapEdgeStore.
+func NewStoreGraph64(store EdgeStore) *StoreGraph64 {
+	if store == nil {
+		store = NewMapEdgeStore()
+	}
+	return &StoreGraph64{
+		store: store,
+		index: make(map[uint64]uint),
+	}
+}
+
+// Link creates a weighted edge between a source-target node pair.
+// If the edge already exists, the weight is incremented.
+func (g *StoreGraph64) Link(source, target uint64, weight float64) {
+	outbound := float64(0)
+	if s, ok := g.index[source]; ok {
+		outbound = g.store.OutSum(s)
+	}
+	if !finite(weight) || !finite(outbound+weight) {
+		g.Rejected++
+		return
+	}
+	g.store.Add(g.add(source), g.add(target), weight)
+}
+
+// AddNode registers a node without any edge, so that it is ranked even if it is
+// never linked.
+func (g *StoreGraph64) AddNode(id uint64) {
+	g.add(id)
+}
+
+// add returns the index of a node, registering it first if needed.
+func (g *StoreGraph64) add(id uint64) uint {
+	i, ok := g.index[id]
+	if !ok {
+		i = uint(len(g.ids))
+		g.index[id] = i
+		g.ids = append(g.ids, id)
+	}
+	return i
+}
+
+// Rank computes the PageRank of every node like Graph64.Rank, reading the edges
+// from the store at every iteration.
+func (g *StoreGraph64) Rank(α, ε float64, callback func(id uint64, rank float64)) {
+	sums := make([]float64, len(g.ids))
+	for i := range sums {
+		sums[i] = g.store.OutSum(uint(i))
+	}
+	power := powerIteration{
+		verbose:       g.Verbose,
+		maxIterations: g.MaxIterations,
+		progress:      g.Progress,
+		dangling: func(source int) bool {
+			return sums[source] > -minOutbound64 && sums[source] < minOutbound64
+		},
+		push: func(source int, rank float64, next []float64) {
+			rank /= sums[source]
+			g.store.Neighbors(uint(source), func(target uint, weight float64) {
+				next[target] += rank * weight
+			})
+		},
+	}
+	ranks := power.rank(len(g.ids), α, ε)
+	g.LastRun = power.run
+
+	for key, value := range g.index {
+		callback(key, ranks[value])
+	}
+}
+
+// powerIterations caps the number of iterations of the rankings of StoreGraph64
+// and FrozenGraph64 without MaxIterations, so that they end even if ε is 0.
+const powerIterations = 1 << 16
+
+// powerIteration is the plain power iteration of the graphs that don't keep their
+// edges in Graph64, which only differ in how they push the rank of a node along
+// its edges.
+type powerIteration struct {
+	verbose       bool
+	maxIterations int
+	progress      func(iteration int, Δ float64)
+	// dangling reports whether a node has no outbound weight to push along.
+	dangling func(source int) bool
+	// push adds rank times the transition probability of every edge of source
+	// to the next rank of its target.
+	push func(source int, rank float64, next []float64)
+	// run is the outcome of the last ranking.
+	run Run64
+}
+
+// rank iterates from the uniform distribution over n nodes until Δ falls below ε
+// or the iterations run out, and returns the ranks.
+func (p *powerIteration) rank(n int, α, ε float64) []float64 {
+	if n == 0 {
+		p.run = Run64{Converged: true, Reason: ReasonConverged}
+		return nil
+	}
+	maxIterations := p.maxIterations
+	if maxIterations <= 0 {
+		maxIterations = powerIterations
+	}
+	inverse := 1 / float64(n)
+	ranks, next := make([]float64, n), make([]float64, n)
+	for i := range ranks {
+		ranks[i] = inverse
+	}
+
+	Δ, iterations, leak, total := float64(1), 0, float64(0), float64(0)
+	reason := ReasonConverged
+	for Δ > ε {
+		if iterations >= maxIterations {
+			reason = ReasonMaxIterations
+			break
+		}
+		if p.verbose {
+			fmt.Println("updating...")
+		}
+		leak = 0
+		for i := range next {
+			next[i] = 0
+		}
+		for source := range ranks {
+			if p.dangling(source) {
+				leak += ranks[source]
+				continue
+			}
+			p.push(source, α*ranks[source], next)
+		}
+		mass := ((1 - α) + α*leak) * inverse
+		Δ = 0
+		for i := range next {
+			next[i] += mass
+			Δ += math.Abs(next[i] - ranks[i])
+		}
+		ranks, next = next, ranks
+		iterations++
+		total += leak
+		if p.verbose {
+			fmt.Println(Δ, ε)
+		}
+		if p.progress != nil {
+			p.progress(iterations, Δ)
+		}
+	}
+	leak = 0
+	for source := range ranks {
+		if p.dangling(source) {
+			leak += ranks[source]
+		}
+	}
+	p.run = Run64{
+		Iterations:  iterations,
+		Delta:       Δ,
+		Converged:   reason == ReasonConverged,
+		Reason:      reason,
+		Leaked:      leak,
+		TotalLeaked: total,
+	}
+	return ranks
+}
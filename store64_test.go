@@ -0,0 +1,64 @@
+package pagerank
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStoreGraph64(t *testing.T) {
+	link := func(link func(source, target uint64, weight float64)) {
+		link(1, 2, 1.0)
+		link(1, 3, 2.0)
+		link(2, 3, 3.0)
+		link(2, 4, 4.0)
+		link(3, 1, 5.0)
+		link(1, 2, 1.0)
+		link(5, 6, 0.0)
+	}
+	graph := NewGraph64()
+	link(graph.Link)
+	expected := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+
+	for _, store := range []EdgeStore{nil, NewMapEdgeStore(), NewSortedEdgeStore()} {
+		stored := NewStoreGraph64(store)
+		link(stored.Link)
+		actual := map[uint64]float64{}
+		stored.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+			actual[node] = rank
+		})
+		if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+			t.Error("Expected", expected, "but got", actual)
+		}
+	}
+
+	stored := NewStoreGraph64(nil)
+	link(stored.Link)
+	stored.MaxIterations = 3
+	progress := 0
+	stored.Progress = func(iteration int, Δ float64) {
+		progress = iteration
+	}
+	stored.Rank(0.85, 0, func(node uint64, rank float64) {})
+	if run := stored.LastRun; run.Iterations != 3 || progress != 3 || run.Converged || run.Reason != ReasonMaxIterations {
+		t.Error("Expected the ranking to stop at 3 iterations but got", run, progress)
+	}
+
+	sorted := NewSortedEdgeStore()
+	sorted.Add(0, 3, 1.0)
+	sorted.Add(0, 1, 2.0)
+	sorted.Add(0, 2, 3.0)
+	sorted.Add(0, 1, 4.0)
+	targets, weights := []uint{}, []float64{}
+	sorted.Neighbors(0, func(target uint, weight float64) {
+		targets, weights = append(targets, target), append(weights, weight)
+	})
+	if !reflect.DeepEqual(targets, []uint{1, 2, 3}) || !reflect.DeepEqual(weights, []float64{6, 3, 1}) {
+		t.Error("Expected sorted edges but got", targets, weights)
+	}
+	if sum := sorted.OutSum(0); sum != 10 {
+		t.Error("Expected 10 but got", sum)
+	}
+}
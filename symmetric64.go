@@ -0,0 +1,85 @@
+package pagerank
+
+import "math"
+
+// RankSymmetric computes the PageRank of every node treating the graph as
+// undirected, with the symmetric normalization W_ij / sqrt(d_i * d_j) used by
+// spectral clustering instead of the row normalization used by Rank. The weight
+// between two nodes is the sum of the weights of the edges linking them in either
+// direction, and the degree of a node is the sum of the weights of its edges.
+// Because the symmetrically normalized matrix is not stochastic, the ranks are
+// rescaled to sum to 1 after every iteration.
+func (g *Graph64) RankSymmetric(α, ε float64, callback func(id uint64, rank float64)) {
+	g.dequantize()
+	n := len(g.nodes)
+	adjacency := make([]map[uint]float64, n)
+	degree := make([]float64, n)
+	link := func(s, t uint, weight float64) {
+		if adjacency[s] == nil {
+			adjacency[s] = make(map[uint]float64)
+		}
+		adjacency[s][t] += weight
+		degree[s] += weight
+	}
+	for source := range g.nodes {
+		node := &g.nodes[source]
+		for target, weight := range node.edges {
+			weight = g.raw(node, weight)
+			link(uint(source), target, weight)
+			link(target, uint(source), weight)
+		}
+	}
+	for source, edges := range adjacency {
+		for target, weight := range edges {
+			// Edges with an endpoint without degree, such as zero weight edges,
+			// carry nothing.
+			if product := degree[source] * degree[target]; product > 0 {
+				edges[target] = weight / math.Sqrt(product)
+			} else {
+				delete(edges, target)
+			}
+		}
+	}
+
+	inverse := 1 / float64(n)
+	ranks, next := make([]float64, n), make([]float64, n)
+	for i := range ranks {
+		ranks[i] = inverse
+	}
+	Δ, iterations := float64(1.0), 0
+	reason := ReasonConverged
+	for Δ > ε {
+		if g.MaxIterations > 0 && iterations >= g.MaxIterations {
+			reason = ReasonMaxIterations
+			break
+		}
+		sum := float64(0)
+		for i := range next {
+			rank := (1 - α) * inverse
+			for j, weight := range adjacency[i] {
+				rank += α * weight * ranks[j]
+			}
+			next[i] = rank
+			sum += rank
+		}
+		Δ = 0
+		for i := range next {
+			if sum > 0 {
+				next[i] /= sum
+			}
+			Δ += math.Abs(next[i] - ranks[i])
+		}
+		ranks, next = next, ranks
+		iterations++
+	}
+	g.LastRun = Run64{
+		Iterations: iterations,
+		Delta:      Δ,
+		Converged:  reason == ReasonConverged,
+		Reason:     reason,
+	}
+
+	for key, value := range g.index {
+		callback(key, ranks[value])
+	}
+}
@@ -0,0 +1,74 @@
+package pagerank
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRankSymmetric64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(1, 3, 2.0)
+	graph.Link(2, 3, 3.0)
+	graph.Link(2, 4, 4.0)
+	graph.Link(3, 1, 5.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.2548653076768908,
+		2: 0.26464469686032543,
+		3: 0.2833394369330744,
+		4: 0.19715055852970936,
+	}
+
+	graph.RankSymmetric(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestRankSymmetricPath64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 1.0)
+
+	actual := map[uint64]float64{}
+	graph.RankSymmetric(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if convert64(actual)[1] != convert64(actual)[3] {
+		t.Error("Expected both ends of the path to have the same rank but got", actual)
+	}
+}
+
+func TestRankSymmetricZeroDegree64(t *testing.T) {
+	graph := NewGraph64()
+	graph.Link(1, 2, 0.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 4, 1.0)
+
+	actual := map[uint64]float64{}
+	graph.RankSymmetric(0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+	for node, rank := range actual {
+		if !finite(rank) {
+			t.Error("Expected a finite rank for", node, "but got", rank)
+		}
+	}
+	if actual[1] >= actual[2] {
+		t.Error("Expected node 1 to only get the teleport probability but got", actual)
+	}
+
+	graph.MaxIterations = 2
+	graph.RankSymmetric(0.85, 0.000000001, func(node uint64, rank float64) {})
+	if run := graph.LastRun; run.Converged || run.Reason != ReasonMaxIterations || run.Iterations != 2 {
+		t.Error("Expected the ranking to stop at MaxIterations but got", run)
+	}
+}
@@ -0,0 +1,123 @@
+package pagerank
+
+import "math"
+
+// typedEdge64 is an edge of a given type between two internal indices.
+type typedEdge64 struct {
+	source, target uint
+	kind           uint8
+}
+
+// LinkTyped creates a weighted edge like Link, and records its weight under an
+// edge type, such as follows or mentions, for RankTypes. The weights of edges of
+// different types between the same nodes add up for Rank. The weight recorded is
+// the one the graph stores, as transformed in LogSpace mode, and nothing is
+// recorded for edges that are rejected or evicted.
+func (g *Graph64) LinkTyped(source, target uint64, weight float64, edgeType uint8) {
+	s, t, stored, scale, changed, err := g.linkStored(source, target, weight)
+	if err != nil {
+		g.Rejected++
+		return
+	}
+	if changed {
+		edges := g.nodes[s].edges
+		for edge, weight := range g.typed {
+			if edge.source != s {
+				continue
+			}
+			if _, ok := edges[edge.target]; ok {
+				g.typed[edge] = weight * scale
+			} else {
+				delete(g.typed, edge)
+			}
+		}
+	}
+	if _, ok := g.nodes[s].edges[t]; !ok {
+		return
+	}
+	if g.typed == nil {
+		g.typed = make(map[typedEdge64]float64)
+	}
+	g.typed[typedEdge64{source: s, target: t, kind: edgeType}] += stored
+}
+
+// linkStored links an edge like LinkChecked and returns the indices of its
+// nodes and the weight the graph added to the edge, as stored. changed reports
+// that the other edges of the source changed too: they were scaled by scale as
+// the log weights were rebased in LogSpace mode, or one of them was evicted by
+// MaxEdgesPerNode, which can be the new edge itself.
+func (g *Graph64) linkStored(source, target uint64, weight float64) (s, t uint, stored, scale float64, changed bool, err error) {
+	g.denormalize()
+	var shift, previous float64
+	count, existed := 0, false
+	if i, ok := g.index[source]; ok {
+		node := &g.nodes[i]
+		shift, count = node.shift, len(node.edges)
+		if j, ok := g.index[target]; ok {
+			previous, existed = node.edges[j]
+		}
+	}
+	if err := g.LinkChecked(source, target, weight); err != nil {
+		return 0, 0, 0, 1, false, err
+	}
+
+	s, t, scale = g.index[source], g.index[target], 1
+	node := &g.nodes[s]
+	if g.LogSpace && count > 0 && node.shift != shift {
+		scale, changed = math.Exp(shift-node.shift), true
+	}
+	if !existed {
+		count++
+	}
+	if len(node.edges) < count {
+		changed = true
+	}
+	return s, t, node.edges[t] - previous*scale, scale, changed, nil
+}
+
+// RankTypes computes the PageRank of every node like Rank, but only along the
+// edges linked by LinkTyped with one of the given types, with the outbound weights
+// of the nodes summed over these edges only. Edges linked by Link have no type and
+// are left out. The ranking runs on a filtered copy of the graph, so the graph
+// itself is left as is.
+func (g *Graph64) RankTypes(types []uint8, α, ε float64, callback func(id uint64, rank float64)) {
+	kept := make(map[uint8]bool, len(types))
+	for _, kind := range types {
+		kept[kind] = true
+	}
+
+	ids := g.ids()
+	g.rankView(func(view *Graph64) {
+		for edge, weight := range g.typed {
+			if kept[edge.kind] {
+				view.Link(ids[edge.source], ids[edge.target], weight)
+			}
+		}
+	}, α, ε, callback)
+}
+
+// rankView ranks a copy of the graph with the same nodes, in the same order, and
+// the edges linked by link, and reports the ranks of the copy. The copy keeps the
+// ranking settings of the graph, its teleport distribution, teleportable nodes,
+// damping factors, restart probabilities and pins; MaxEdgesPerNode, LogSpace,
+// StrictNodes and constraints are left out, as the edges are linked anew.
+func (g *Graph64) rankView(link func(view *Graph64), α, ε float64, callback func(id uint64, rank float64)) {
+	ids := g.ids()
+	view := NewGraph64(len(ids))
+	g.copySettings(view)
+	for _, id := range ids {
+		view.add(id)
+	}
+	// The view has the same internal indices, so the settings by index carry over.
+	view.pins, view.noTeleport = g.pins, g.noTeleport
+	view.damping, view.restart = g.damping, g.restart
+	view.preference = g.preference
+	link(view)
+
+	a := view.iterate(&settings64{α: α, ε: ε})
+	g.LastRun = view.LastRun
+
+	for key, value := range view.index {
+		callback(key, view.nodes[value].weight[a])
+	}
+}
@@ -0,0 +1,112 @@
+package pagerank
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	follows uint8 = iota
+	likes
+	mentions
+)
+
+func TestRankTypes64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.LinkTyped(1, 2, 1.0, follows)
+	graph.LinkTyped(1, 3, 2.0, follows)
+	graph.LinkTyped(2, 3, 3.0, likes)
+	graph.LinkTyped(2, 4, 4.0, follows)
+	graph.LinkTyped(3, 1, 5.0, likes)
+	graph.LinkTyped(3, 4, 5.0, mentions)
+	graph.LinkTyped(1, 4, 2.0, mentions)
+	graph.Link(4, 1, 1.0)
+
+	actual := map[uint64]float64{}
+	expected := map[uint64]float64{
+		1: 0.34983779905464363,
+		2: 0.1688733284604475,
+		3: 0.3295121849483849,
+		4: 0.15177668753652385,
+	}
+
+	graph.RankTypes([]uint8{follows, likes}, 0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	all := NewGraph64()
+	all.Link(1, 2, 1.0)
+	all.Link(1, 3, 2.0)
+	all.Link(2, 3, 3.0)
+	all.Link(2, 4, 4.0)
+	all.Link(3, 1, 5.0)
+	all.Link(3, 4, 5.0)
+	all.Link(1, 4, 2.0)
+	all.Link(4, 1, 1.0)
+	if !graph.Equal(all) {
+		t.Error("Expected the typed edges to add up in the graph")
+	}
+}
+
+func TestRankTypesTeleport64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.LinkTyped(1, 2, 1.0, follows)
+	graph.LinkTyped(1, 3, 2.0, follows)
+	graph.LinkTyped(2, 3, 3.0, follows)
+	graph.LinkTyped(2, 4, 4.0, follows)
+	graph.LinkTyped(3, 1, 5.0, follows)
+	graph.LinkTyped(4, 1, 1.0, follows)
+	graph.SetTeleport(map[uint64]float64{2: 1})
+	graph.SetTeleportable(3, false)
+	graph.SetDamping(4, 0.5)
+
+	expected := map[uint64]float64{}
+	graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+		expected[node] = rank
+	})
+	actual := map[uint64]float64{}
+	graph.RankTypes([]uint8{follows}, 0.85, 0.000001, func(node uint64, rank float64) {
+		actual[node] = rank
+	})
+
+	if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+		t.Error("Expected", expected, "but got", actual)
+	}
+}
+
+func TestLinkTypedStored64(t *testing.T) {
+	for _, setup := range []func(graph *Graph64){
+		func(graph *Graph64) { graph.LogSpace = true },
+		func(graph *Graph64) { graph.MaxEdgesPerNode = 2 },
+	} {
+		graph := NewGraph64()
+		setup(graph)
+
+		graph.LinkTyped(1, 2, 1.0, follows)
+		graph.LinkTyped(1, 3, 2.0, likes)
+		graph.LinkTyped(1, 4, 3.0, follows)
+		graph.LinkTyped(1, 3, 1.0, follows)
+		graph.LinkTyped(2, 1, 1.0, likes)
+		graph.LinkTyped(3, 1, 5.0, mentions)
+		graph.LinkTyped(4, 2, 2.0, follows)
+
+		expected := map[uint64]float64{}
+		graph.Rank(0.85, 0.000001, func(node uint64, rank float64) {
+			expected[node] = rank
+		})
+		actual := map[uint64]float64{}
+		graph.RankTypes([]uint8{follows, likes, mentions}, 0.85, 0.000001, func(node uint64, rank float64) {
+			actual[node] = rank
+		})
+
+		if reflect.DeepEqual(convert64(actual), convert64(expected)) != true {
+			t.Error("Expected", expected, "but got", actual)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package pagerank
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// RandomWalk returns a random walk of the given length starting at start.
+// At every step the walk follows an outbound edge with probability α, or the
+// damping factor of the node set by SetDamping or SetRestart, choosing the edge
+// proportionally to its normalized weight, and otherwise teleports to a node
+// chosen along the teleport distribution set by SetTeleport and
+// SetTeleportable, uniformly by default, as does the random surfer of PageRank.
+// Dangling nodes always teleport. The walk is deterministic for a given rng.
+func (g *Graph64) RandomWalk(start uint64, length int, α float64, rng *rand.Rand) []uint64 {
+	s, ok := g.index[start]
+	if !ok || length <= 0 {
+		return nil
+	}
+
+	g.normalize()
+
+	ids := g.ids()
+	// cumulative is the cumulative teleport distribution, nil if it is uniform.
+	var cumulative []float64
+	if teleport := g.teleport(nil); teleport != nil {
+		cumulative = make([]float64, len(teleport))
+		sum := float64(0)
+		for i, p := range teleport {
+			sum += p
+			cumulative[i] = sum
+		}
+	}
+	targets := make(map[uint][]uint)
+	walk := make([]uint64, 0, length)
+	walk = append(walk, start)
+	for len(walk) < length {
+		node := &g.nodes[s]
+		if node.dangling() || len(node.edges) == 0 || rng.Float64() >= g.alpha(s, α) {
+			if cumulative == nil {
+				s = uint(rng.Intn(len(g.nodes)))
+			} else {
+				r := rng.Float64() * cumulative[len(cumulative)-1]
+				i := sort.Search(len(cumulative), func(i int) bool { return cumulative[i] > r })
+				if i == len(cumulative) {
+					i--
+				}
+				s = uint(i)
+			}
+			walk = append(walk, ids[s])
+			continue
+		}
+
+		sorted, ok := targets[s]
+		if !ok {
+			sorted = make([]uint, 0, len(node.edges))
+			for target := range node.edges {
+				sorted = append(sorted, target)
+			}
+			sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+			targets[s] = sorted
+		}
+
+		r, next := rng.Float64(), sorted[len(sorted)-1]
+		for _, target := range sorted {
+			if r -= node.edges[target]; r < 0 {
+				next = target
+				break
+			}
+		}
+		s = next
+		walk = append(walk, ids[s])
+	}
+	return walk
+}
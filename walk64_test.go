@@ -0,0 +1,41 @@
+package pagerank
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestRandomWalk64(t *testing.T) {
+	graph := NewGraph64()
+
+	graph.Link(1, 2, 1.0)
+	graph.Link(2, 3, 1.0)
+	graph.Link(3, 1, 1.0)
+
+	rng := rand.New(rand.NewSource(1))
+	actual := graph.RandomWalk(1, 5, 1.0, rng)
+	expected := []uint64{1, 2, 3, 1, 2}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	a := graph.RandomWalk(2, 100, 0.5, rand.New(rand.NewSource(2)))
+	b := graph.RandomWalk(2, 100, 0.5, rand.New(rand.NewSource(2)))
+	if len(a) != 100 || !reflect.DeepEqual(a, b) {
+		t.Error("Expected identical walks for the same seed")
+	}
+
+	graph.SetTeleport(map[uint64]float64{3: 1})
+	for _, id := range graph.RandomWalk(1, 100, 0, rng)[1:] {
+		if id != 3 {
+			t.Error("Expected every restart to teleport to node 3 but got", id)
+			break
+		}
+	}
+	graph.SetTeleport(nil)
+
+	if walk := graph.RandomWalk(4, 5, 0.85, rng); walk != nil {
+		t.Error("Expected no walk from a missing node but got", walk)
+	}
+}